@@ -0,0 +1,54 @@
+package storm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// WithAdvisoryLock runs fn while holding the postgres advisory lock key, it waits until the lock
+// is free or ctx is done. Use it to make sure a singleton job runs on only one instance at a time:
+//
+//	err := db.WithAdvisoryLock(ctx, 42, func() error {
+//		return sendDailyReport()
+//	})
+//
+// The lock is released when fn returns, even if it panics. Advisory locks belong to a connection,
+// so storm holds a dedicated one from the pool for the whole call, or use the connection of the
+// transaction when called on a Tx.
+func (s *Storm) WithAdvisoryLock(ctx context.Context, key int64, fn func() error) (err error) {
+	if s.dialect.Name() != "postgres" {
+		return fmt.Errorf("storm: advisory locks are only supported on postgres, not %s", s.dialect.Name())
+	}
+
+	session := s.Session(&SessionConfig{Context: ctx})
+	if !s.inTx() {
+		conn, err := s.db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		session.conn = conn
+	}
+
+	if _, err := session.exec("SELECT pg_advisory_lock($1)", key); err != nil {
+		return fmt.Errorf("storm: acquire advisory lock %d: %v", key, err)
+	}
+
+	defer func() {
+		// unlock even when ctx is cancelled, otherwise the lock stays on the connection
+		unlock := session.WithContext(context.WithoutCancel(ctx))
+		if _, unlockErr := unlock.exec("SELECT pg_advisory_unlock($1)", key); unlockErr != nil {
+			// the lock is still held, don't give this connection back to the pool
+			if conn, ok := session.conn.(*sql.Conn); ok {
+				conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+			}
+			if err == nil {
+				err = fmt.Errorf("storm: release advisory lock %d: %v", key, unlockErr)
+			}
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,68 @@
+package storm
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// WhereAny adds the condition `column = ANY($1)`, values (a slice) is bound as a single
+// postgres array with pq.Array. Unlike IN, the statement is the same whatever the number of
+// values, which plays well with prepared statements:
+//
+//	db.From(&User{}).WhereAny("id", []int64{1, 2, 3}).Select(&users) // WHERE id = ANY($1)
+//
+// The other databases have no arrays, there the condition is expanded like In. An empty slice
+// matches no row and a nil values is an error. An array that is already a driver.Valuer, like
+// pq.StringArray, is bound as it is.
+func (q *Query) WhereAny(column string, values interface{}) *Query {
+	if !isArray(values) {
+		q.err = fmt.Errorf("storm: WhereAny values must be a slice, got %T", values)
+		return q
+	}
+	if q.storm.dialect.Name() != "postgres" {
+		return q.WhereExpr(In{column: values})
+	}
+	q.where = append(q.where, rawExpr{column + " = ANY($1)", []interface{}{arrayArg(values)}})
+	return q
+}
+
+// WhereAll adds the condition `column op ALL($1)`, values (a slice) is bound as a single
+// postgres array, for example WhereAll("price", ">", prices) or WhereAll("status", "<>", banned),
+// which is NOT IN. It is only supported by postgres.
+func (q *Query) WhereAll(column, op string, values interface{}) *Query {
+	if !isArray(values) {
+		q.err = fmt.Errorf("storm: WhereAll values must be a slice, got %T", values)
+		return q
+	}
+	if q.storm.dialect.Name() != "postgres" {
+		q.err = fmt.Errorf("storm: WhereAll is only supported on postgres")
+		return q
+	}
+	switch op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+	default:
+		q.err = fmt.Errorf("storm: invalid comparison operator %q in WhereAll", op)
+		return q
+	}
+	q.where = append(q.where, rawExpr{column + " " + op + " ALL($1)", []interface{}{arrayArg(values)}})
+	return q
+}
+
+// isArray reports whether values can be bound as a postgres array, a slice or a driver.Valuer
+// that is one.
+func isArray(values interface{}) bool {
+	if _, ok := values.(driver.Valuer); ok {
+		return true
+	}
+	return isList(values)
+}
+
+// arrayArg returns the argument binding values as a postgres array.
+func arrayArg(values interface{}) interface{} {
+	if valuer, ok := values.(driver.Valuer); ok {
+		return valuer
+	}
+	return pq.Array(values)
+}
@@ -0,0 +1,195 @@
+// Package audit is a storm plugin that records every Insert, Update and Delete into an audit table.
+//
+//	err := db.Use(audit.New(audit.Config{
+//		Actor: func(ctx context.Context) string { return userFrom(ctx) },
+//	}))
+//
+// Each change is one row with the table, the primary key, the action, the old and new column
+// values as JSON, the actor and the time. The row is written with the same session as the
+// change, so inside a transaction both are committed or rolled back together. The updates
+// and deletes that matched no row are not recorded.
+//
+// The table must exist, on postgres:
+//
+//	CREATE TABLE audit_logs (
+//		id BIGSERIAL PRIMARY KEY,
+//		table_name TEXT NOT NULL,
+//		record_id TEXT NOT NULL,
+//		action TEXT NOT NULL,
+//		old_values TEXT,
+//		new_values TEXT,
+//		actor TEXT,
+//		created_at TIMESTAMP NOT NULL
+//	)
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/pepega90/storm"
+)
+
+// Actions recorded in the action column.
+const (
+	ActionInsert = "insert"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Config configures the audit plugin, zero values use the default.
+type Config struct {
+	Table string                           // Table, where the changes are recorded, default audit_logs
+	Actor func(ctx context.Context) string // Actor, who made the change, from the context of the session
+	Skip  func(table string) bool          // Skip, tables that are not audited
+}
+
+// oldValuesKey, the write value holding the old values of the row being updated or deleted,
+// set in the Before callback and read in the After one, see storm.SetWriteValue
+const oldValuesKey = "audit:old_values"
+
+// Plugin is the audit plugin, create it with New and register it with storm.Use.
+type Plugin struct {
+	config Config
+}
+
+// New returns the audit plugin configured with config.
+func New(config Config) *Plugin {
+	if config.Table == "" {
+		config.Table = "audit_logs"
+	}
+	return &Plugin{config: config}
+}
+
+// Name implements storm.Plugin.
+func (p *Plugin) Name() string {
+	return "audit"
+}
+
+// Initialize implements storm.Plugin, it registers the callbacks of the plugin.
+func (p *Plugin) Initialize(s *storm.Storm) error {
+	s.RegisterCallback(storm.HookBeforeUpdate, p.loadOld)
+	s.RegisterCallback(storm.HookBeforeDelete, p.loadOld)
+	s.RegisterCallback(storm.HookAfterInsert, p.record(ActionInsert))
+	s.RegisterCallback(storm.HookAfterUpdate, p.record(ActionUpdate))
+	s.RegisterCallback(storm.HookAfterDelete, p.record(ActionDelete))
+	return nil
+}
+
+// loadOld reads the row model is about to change, so its old values can be recorded.
+func (p *Plugin) loadOld(s *storm.Storm, model interface{}) error {
+	info, err := s.Schema(model)
+	if err != nil || info.PK == nil || p.skip(info.Table) {
+		return nil
+	}
+
+	old := reflect.New(info.Type)
+	pk := info.PK.Value(reflect.ValueOf(model).Elem()).Interface()
+	err = s.Session(&storm.SessionConfig{SkipHooks: true}).Get(old.Interface(), pk)
+	if err == storm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("audit: load %s %v: %v", info.Table, pk, err)
+	}
+
+	// kept by the write itself, so a failed one leaves nothing behind
+	s.SetWriteValue(oldValuesKey, values(info, old.Elem()))
+	return nil
+}
+
+// record returns the callback that records the action done on model.
+func (p *Plugin) record(action string) storm.Callback {
+	return func(s *storm.Storm, model interface{}) error {
+		info, err := s.Schema(model)
+		if err != nil || p.skip(info.Table) {
+			return nil
+		}
+		// an update or delete that matched no row changed nothing
+		if action != ActionInsert && s.RowsAffected() == 0 {
+			return nil
+		}
+		val := reflect.ValueOf(model).Elem()
+
+		var recordID interface{}
+		if info.PK != nil {
+			recordID = info.PK.Value(val).Interface()
+		}
+
+		var old map[string]interface{}
+		if pending, ok := s.WriteValue(oldValuesKey); ok {
+			old = pending.(map[string]interface{})
+		}
+
+		var oldValues, newValues interface{}
+		if old != nil {
+			if oldValues, err = encode(old); err != nil {
+				return err
+			}
+		}
+		switch action {
+		case ActionInsert:
+			newValues, err = encode(values(info, val))
+		case ActionUpdate:
+			newValues, err = encode(updated(info, val, old))
+		}
+		if err != nil {
+			return err
+		}
+
+		actor := ""
+		if p.config.Actor != nil {
+			actor = p.config.Actor(s.Context())
+		}
+
+		_, err = s.InsertInto(p.config.Table).
+			Columns("table_name", "record_id", "action", "old_values", "new_values", "actor", "created_at").
+			Values(info.Table, fmt.Sprint(recordID), action, oldValues, newValues, actor, time.Now()).
+			Exec()
+		if err != nil {
+			return fmt.Errorf("audit: record %s of %s: %v", action, info.Table, err)
+		}
+		return nil
+	}
+}
+
+// skip reports whether table is not audited.
+func (p *Plugin) skip(table string) bool {
+	return table == p.config.Table || (p.config.Skip != nil && p.config.Skip(table))
+}
+
+// values returns the column values of the model struct val.
+func values(info *storm.Schema, val reflect.Value) map[string]interface{} {
+	result := make(map[string]interface{}, len(info.Fields))
+	for _, field := range info.Fields {
+		result[field.Column] = field.Value(val).Interface()
+	}
+	return result
+}
+
+// updated returns the column values of the row after Update changed it with the model struct val:
+// Update only writes the non-zero fields, the other columns keep their old value.
+func updated(info *storm.Schema, val reflect.Value, old map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{}, len(info.Fields))
+	for col, v := range old {
+		result[col] = v
+	}
+	for _, field := range info.Fields {
+		if fieldVal := field.Value(val); !fieldVal.IsZero() || field.PK {
+			result[field.Column] = fieldVal.Interface()
+		}
+	}
+	return result
+}
+
+// encode returns the values as JSON text.
+func encode(values map[string]interface{}) (string, error) {
+	doc, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("audit: encode values: %v", err)
+	}
+	return string(doc), nil
+}
@@ -0,0 +1,192 @@
+package storm
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Batch queues write statements and sends them to the database together with Flush,
+// to cut the round trips of a burst of writes. Create it with Storm.Batch.
+type Batch struct {
+	storm *Storm // storm, the session the statements are flushed with
+	queue *Storm // queue, dry run session of storm, it builds and records the queued statements
+}
+
+// Batch returns an empty batch of s. The statements are built right away, with the Before
+// hooks of the models, and sent when Flush is called:
+//
+//	batch := db.Batch()
+//	for _, item := range items {
+//		if err := batch.Insert(&item); err != nil {
+//			return err
+//		}
+//	}
+//	batch.Exec("UPDATE carts SET checked_out = TRUE WHERE id = $1", cartID)
+//	err := batch.Flush() // one round trip on postgres
+//
+// On postgres every statement is sent in one multi-statement exec, which the server runs in
+// one implicit transaction (or in the transaction of s), the arguments are written in the SQL
+// as quoted literals for that. The other dialects run the statements one by one in a transaction.
+// The After hooks are not called and the keys generated by the database are not read back.
+func (s *Storm) Batch() *Batch {
+	return &Batch{storm: s, queue: s.DryRun()}
+}
+
+// Insert queues the INSERT of model, see Storm.Insert.
+func (b *Batch) Insert(model interface{}) error {
+	return b.queue.Insert(model)
+}
+
+// Update queues the UPDATE of model, see Storm.Update. Its optimistic locking is not checked.
+func (b *Batch) Update(model interface{}) error {
+	_, err := b.queue.Update(model)
+	return err
+}
+
+// Delete queues the DELETE of model, see Storm.Delete.
+func (b *Batch) Delete(model interface{}) error {
+	_, err := b.queue.Delete(model)
+	return err
+}
+
+// Exec queues a hand written statement with its arguments, numbered $1, $2, ...
+func (b *Batch) Exec(query string, args ...interface{}) error {
+	_, err := b.queue.exec(query, args...)
+	return err
+}
+
+// Len returns the number of statements waiting for Flush.
+func (b *Batch) Len() int {
+	return len(*b.queue.recorded)
+}
+
+// Flush sends the queued statements to the database and empties the batch, even when it fails.
+// Either every statement is applied or none is.
+func (b *Batch) Flush() error {
+	stmts := *b.queue.recorded
+	*b.queue.recorded = nil
+	if len(stmts) == 0 {
+		return nil
+	}
+
+	s := b.storm
+	if s.dialect.Name() == "postgres" {
+		queries := make([]string, len(stmts))
+		for i, stmt := range stmts {
+			query, err := inlineArgs(strings.TrimSpace(stmt.SQL), stmt.Args)
+			if err != nil {
+				return err
+			}
+			queries[i] = query
+		}
+		_, err := s.execBound(strings.Join(queries, ";\n"), nil)
+		return err
+	}
+
+	run := func(tx *Storm) error {
+		for _, stmt := range stmts {
+			if _, err := tx.execBound(stmt.SQL, stmt.Args); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if s.inTx() {
+		return run(s)
+	}
+	return s.Transaction(func(tx *Tx) error {
+		return run(tx.Storm)
+	})
+}
+
+// inlineArgs replaces the $n placeholders of query, a postgres statement, with args quoted as
+// literals, so it can be sent with other statements in one simple query. The placeholders
+// inside quoted strings are left alone, like shiftPlaceholders does.
+func inlineArgs(query string, args []interface{}) (string, error) {
+	if len(args) == 0 {
+		return query, nil
+	}
+
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c == '\'' {
+			inQuote = !inQuote
+		}
+		if c != '$' || inQuote || i+1 >= len(query) || !isDigit(query[i+1]) {
+			b.WriteByte(c)
+			continue
+		}
+
+		j := i + 1
+		for j < len(query) && isDigit(query[j]) {
+			j++
+		}
+		n, _ := strconv.Atoi(query[i+1 : j])
+		if n < 1 || n > len(args) {
+			return "", fmt.Errorf("storm: batch statement has no argument for $%d: %s", n, query)
+		}
+		literal, err := argLiteral(args[n-1])
+		if err != nil {
+			return "", fmt.Errorf("storm: batch argument $%d: %v", n, err)
+		}
+		b.WriteString(literal)
+		i = j - 1
+	}
+	return b.String(), nil
+}
+
+// argLiteral returns arg as a postgres literal, like sqlLiteral but for any argument of a statement:
+// it is converted like the driver would first, so the driver.Valuer (arrays, json, ...) give
+// their database value, and times keep their offset.
+func argLiteral(arg interface{}) (string, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+	if err != nil {
+		return "", err
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return "NULL", nil
+	case int64:
+		if v < 0 {
+			// in parentheses, so `x-$1` doesn't become the comment `x--1`
+			return "(" + strconv.FormatInt(v, 10) + ")", nil
+		}
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "'NaN'", nil
+		case math.IsInf(v, 1):
+			return "'Infinity'", nil
+		case math.IsInf(v, -1):
+			return "'-Infinity'", nil
+		}
+		if v < 0 {
+			return "(" + strconv.FormatFloat(v, 'g', -1, 64) + ")", nil
+		}
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case string:
+		return pq.QuoteLiteral(v), nil
+	case []byte:
+		return pq.QuoteLiteral(`\x`+hex.EncodeToString(v)) + "::bytea", nil
+	case time.Time:
+		return pq.QuoteLiteral(v.Format("2006-01-02 15:04:05.999999999Z07:00")), nil
+	default:
+		return "", fmt.Errorf("unsupported value %T", v)
+	}
+}
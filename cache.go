@@ -0,0 +1,120 @@
+package storm
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Cache is a second-level cache of models, shared between the instances of an application,
+// see WithCache. The rediscache package provides a Redis implementation.
+type Cache interface {
+	// Get returns the value of key, found is false when the key is not in the cache.
+	Get(ctx context.Context, key string) (value []byte, found bool, err error)
+	// Set stores value under key for ttl, 0 means no expiration.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key from the cache, deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// modelCache, is the configured cache and how long models stay in it
+type modelCache struct {
+	cache Cache
+	ttl   time.Duration
+}
+
+// WithCache reads the models loaded by primary key (Get and Find) through cache, keeping them ttl.
+// Update, Delete and Upsert of a model remove it from the cache right after the statement, and
+// again once the write is committed (write-through invalidation). The writes that don't know the
+// rows they change (DeleteWhere, UpdateTable, DeleteFrom, DeleteReturning, DeleteBatched) forget
+// every cached row of their table. Statements run with Exec are not seen by the cache.
+//
+// Only the reads that see every row of the table use the cache: a model under a global scope,
+// a SoftDeletable model or a subtype is always read from the database, unless the session is
+// Unscoped, so a row is never served to a reader that must not see it.
+// Reads inside a transaction always go to the database.
+func WithCache(cache Cache, ttl time.Duration) Option {
+	return func(s *Storm) {
+		s.cache = &modelCache{cache: cache, ttl: ttl}
+		s.OnChange(func(e ChangeEvent) {
+			s.forgetCached(e.Table, e.Model)
+		})
+	}
+}
+
+// cacheKey returns the cache key of the row of table with primary key id, in the current
+// generation of the table, which forgetCachedTable moves forward.
+func (s *Storm) cacheKey(table string, id interface{}) string {
+	generation := "0"
+	if value, found, err := s.cache.cache.Get(s.ctx, generationKey(table)); err == nil && found {
+		generation = string(value)
+	}
+	return fmt.Sprintf("storm:%s:%s:%v", table, generation, id)
+}
+
+// generationKey returns the cache key of the generation of table.
+func generationKey(table string) string {
+	return fmt.Sprintf("storm:%s:generation", table)
+}
+
+// useCache reports whether reads of this handle go through the cache.
+func (s *Storm) useCache() bool {
+	if s.cache == nil || s.dryRun {
+		return false
+	}
+	return !s.inTx()
+}
+
+// unfiltered reports whether the reads of model by this handle see every row of its table, no
+// global scope, soft delete or subtype condition applies. Only those reads use the cache, so
+// a row cached by a tenant, or by an Unscoped session, is only served to readers seeing it too.
+func (s *Storm) unfiltered(model interface{}) bool {
+	return len(s.From(model).conditions(nil)) == 0
+}
+
+// cachedGet loads dest from the cache under key.
+// Any error of the cache is a miss, the database is the source of truth.
+func (s *Storm) cachedGet(key string, dest interface{}) bool {
+	value, found, err := s.cache.cache.Get(s.ctx, key)
+	if err != nil || !found {
+		return false
+	}
+	return gob.NewDecoder(bytes.NewReader(value)).Decode(dest) == nil
+}
+
+// cacheSet stores dest in the cache under key.
+func (s *Storm) cacheSet(key string, dest interface{}) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(dest); err != nil {
+		return
+	}
+	s.cache.cache.Set(s.ctx, key, buf.Bytes(), s.cache.ttl)
+}
+
+// forgetCached removes model, stored in table, from the cache after it was written.
+func (s *Storm) forgetCached(table string, model interface{}) {
+	if s.cache == nil || s.dryRun {
+		return
+	}
+	val := reflect.ValueOf(model).Elem()
+	info := s.schema.parseType(val.Type())
+	if info.PK == nil {
+		return
+	}
+	s.cache.cache.Delete(s.ctx, s.cacheKey(table, info.PK.Value(val).Interface()))
+}
+
+// forgetCachedTable forgets every cached row of table, after a write that doesn't know the rows
+// it changed. The generation of the table moves forward, so the keys of the cached rows are
+// not used anymore, they expire with their ttl.
+func (s *Storm) forgetCachedTable(table string) {
+	if s.cache == nil || s.dryRun {
+		return
+	}
+	generation := strconv.FormatInt(time.Now().UnixNano(), 10)
+	s.cache.cache.Set(s.ctx, generationKey(table), []byte(generation), 0)
+}
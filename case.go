@@ -0,0 +1,138 @@
+package storm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CaseExpr is a CASE WHEN expression built with Case, usable in the select list (SelectCase)
+// and in ORDER BY (OrderByCase).
+type CaseExpr struct {
+	whens []caseWhen
+	els   interface{}
+	hasEl bool
+	alias string
+	err   error
+}
+
+// caseWhen, is one WHEN condition THEN value branch
+type caseWhen struct {
+	cond  Expr
+	value interface{}
+}
+
+// Case starts a CASE expression:
+//
+//	tier := storm.Case().
+//		When(storm.GtOrEq{"total_spent": 10000}, "gold").
+//		When(storm.GtOrEq{"total_spent": 1000}, "silver").
+//		Else("bronze").
+//		As("tier")
+//	db.From(&Customer{}).SelectCase(tier).Select(&customers)
+//
+// is `CASE WHEN total_spent >= $1 THEN $2 WHEN total_spent >= $3 THEN $4 ELSE $5 END AS tier`,
+// the alias is mapped to the field Tier (or the field with column tier), like SelectAs.
+// Numbers and booleans are written as literals so the database types the result as a number,
+// other values are bound.
+func Case() *CaseExpr {
+	return &CaseExpr{}
+}
+
+// When adds a branch, value is the result when condition match. condition is an Expr or a
+// SQL string without arguments, like "deleted_at IS NULL".
+func (c *CaseExpr) When(condition interface{}, value interface{}) *CaseExpr {
+	var cond Expr
+	switch v := condition.(type) {
+	case Expr:
+		cond = v
+	case string:
+		cond = rawExpr{v, nil}
+	default:
+		c.err = fmt.Errorf("storm: unsupported CASE condition type %T", condition)
+		return c
+	}
+	c.whens = append(c.whens, caseWhen{cond, value})
+	return c
+}
+
+// Else sets the result when no branch match, without it the result is NULL.
+func (c *CaseExpr) Else(value interface{}) *CaseExpr {
+	c.els = value
+	c.hasEl = true
+	return c
+}
+
+// As sets the name the expression is selected as, needed by SelectCase.
+func (c *CaseExpr) As(alias string) *CaseExpr {
+	c.alias = alias
+	return c
+}
+
+// ToSQL builds the CASE expression, without its alias, so it is an Expr too.
+func (c *CaseExpr) ToSQL() (string, []interface{}, error) {
+	if c.err != nil {
+		return "", nil, c.err
+	}
+	if len(c.whens) == 0 {
+		return "", nil, fmt.Errorf("storm: CASE needs at least one When")
+	}
+
+	args := newParams()
+	var b strings.Builder
+	b.WriteString("CASE")
+	for _, w := range c.whens {
+		cond, condArgs, err := w.cond.ToSQL()
+		if err != nil {
+			return "", nil, err
+		}
+		b.WriteString(" WHEN " + shiftPlaceholders(cond, len(args.args)))
+		args.args = append(args.args, condArgs...)
+		b.WriteString(" THEN " + caseValue(args, w.value))
+	}
+	if c.hasEl {
+		b.WriteString(" ELSE " + caseValue(args, c.els))
+	}
+	b.WriteString(" END")
+	return b.String(), args.args, nil
+}
+
+// caseValue returns the SQL of a result value, numbers and booleans are literals,
+// nil is NULL, anything else is bound with args.
+func caseValue(args *params, v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "NULL"
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, bool:
+		literal, _ := sqlLiteral(v)
+		return literal
+	}
+	return args.add(v)
+}
+
+// SelectCase selects the CASE expression c under its alias (see Case.As), after the other columns.
+func (q *Query) SelectCase(c *CaseExpr) *Query {
+	if c.alias == "" {
+		q.err = fmt.Errorf("storm: SelectCase needs an alias, see CaseExpr.As")
+		return q
+	}
+	sql, args, err := c.ToSQL()
+	if err != nil {
+		q.err = err
+		return q
+	}
+	return q.SelectAs(sql, c.alias, args...)
+}
+
+// OrderByCase orders the results by the CASE expression c, for custom orders,
+// NullsFirst and NullsLast apply to it like to columns:
+//
+//	.OrderByCase(storm.Case().When(storm.Eq{"status": "urgent"}, 0).Else(1))
+func (q *Query) OrderByCase(c *CaseExpr) *Query {
+	sql, args, err := c.ToSQL()
+	if err != nil {
+		q.err = err
+		return q
+	}
+	q.orders = append(q.orders, orderTerm{expr: rawExpr{sql, args}})
+	return q
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/pepega90/storm"
+)
+
+// column, is a column of a table as read from information_schema
+type column struct {
+	Table    string `storm:"column:table_name"`
+	Name     string `storm:"column:column_name"`
+	DataType string `storm:"column:data_type"`
+	Nullable string `storm:"column:is_nullable"`
+	Position int    `storm:"column:ordinal_position"`
+}
+
+// genModels writes a Go file with the struct of every table of the database in dir.
+func genModels(db *storm.Storm, dir, pkg string) error {
+	schemaCond := "table_schema = current_schema()"
+	if db.Dialect().Name() == "mysql" {
+		schemaCond = "table_schema = DATABASE()"
+	}
+
+	var columns []column
+	err := db.Unscoped().Table("information_schema.columns").
+		Where(schemaCond).
+		Select(&columns, "table_name", "column_name", "data_type", "is_nullable", "ordinal_position")
+	if err != nil {
+		return err
+	}
+
+	pks, err := primaryKeys(db, schemaCond)
+	if err != nil {
+		return err
+	}
+
+	byTable := map[string][]column{}
+	var tables []string
+	for _, col := range columns {
+		if col.Table == "storm_migrations" || col.Table == "storm_seeds" {
+			continue
+		}
+		if _, ok := byTable[col.Table]; !ok {
+			tables = append(tables, col.Table)
+		}
+		byTable[col.Table] = append(byTable[col.Table], col)
+	}
+	if len(tables) == 0 {
+		return fmt.Errorf("no table found")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, table := range tables {
+		path := filepath.Join(dir, strings.TrimSuffix(table, "s")+".go")
+		src, err := modelSource(pkg, table, byTable[table], pks[table])
+		if err != nil {
+			return fmt.Errorf("%s: %v", table, err)
+		}
+		if err := os.WriteFile(path, src, 0o644); err != nil {
+			return err
+		}
+		fmt.Println("generated", path)
+	}
+	return nil
+}
+
+// primaryKeys returns the primary key column of every table, by table name.
+func primaryKeys(db *storm.Storm, schemaCond string) (map[string]string, error) {
+	query := `SELECT k.table_name, k.column_name
+		FROM information_schema.table_constraints t
+		JOIN information_schema.key_column_usage k
+		  ON k.constraint_name = t.constraint_name AND k.table_schema = t.table_schema AND k.table_name = t.table_name
+		WHERE t.constraint_type = 'PRIMARY KEY' AND t.` + schemaCond
+
+	rows, err := db.DB().QueryContext(db.Context(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pks := map[string]string{}
+	for rows.Next() {
+		var table, col string
+		if err := rows.Scan(&table, &col); err != nil {
+			return nil, err
+		}
+		// composite keys have no single pk field, storm models need one
+		if _, ok := pks[table]; ok {
+			pks[table] = ""
+			continue
+		}
+		pks[table] = col
+	}
+	return pks, rows.Err()
+}
+
+// modelSource returns the formatted Go source of the model of table.
+func modelSource(pkg, table string, columns []column, pk string) ([]byte, error) {
+	var fields bytes.Buffer
+	imports := map[string]bool{}
+	for _, col := range columns {
+		goType, imp := goType(col.DataType, col.Nullable == "YES" && col.Name != pk)
+		if imp != "" {
+			imports[imp] = true
+		}
+
+		tag := "column:" + col.Name
+		if col.Name == pk {
+			tag = "pk;" + tag
+		}
+		fmt.Fprintf(&fields, "\t%s %s `storm:\"%s\" json:\"%s\"`\n", camelCase(col.Name), goType, tag, col.Name)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by storm gen models. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	if imports["time"] {
+		src.WriteString("import \"time\"\n\n")
+	}
+	name := camelCase(strings.TrimSuffix(table, "s"))
+	fmt.Fprintf(&src, "// %s is a row of the %s table.\ntype %s struct {\n%s}\n", name, table, name, fields.String())
+
+	return format.Source(src.Bytes())
+}
+
+// goType returns the Go type of the SQL data type, and the package it needs to import.
+func goType(dataType string, nullable bool) (string, string) {
+	var t, imp string
+	switch strings.ToLower(dataType) {
+	case "smallint", "integer", "int", "mediumint", "tinyint":
+		t = "int"
+	case "bigint":
+		t = "int64"
+	case "real", "float":
+		t = "float32"
+	case "double precision", "double", "numeric", "decimal":
+		t = "float64"
+	case "boolean", "bool":
+		t = "bool"
+	case "bytea", "blob", "binary", "varbinary":
+		return "[]byte", ""
+	case "timestamp", "timestamp without time zone", "timestamp with time zone", "date", "datetime":
+		t, imp = "time.Time", "time"
+	case "interval":
+		t, imp = "time.Duration", "time"
+	default:
+		t = "string"
+	}
+	if nullable {
+		t = "*" + t
+	}
+	return t, imp
+}
+
+// camelCase converts a snake_case name to an exported Go name, with the common initialisms upper case:
+// user_id is UserID.
+func camelCase(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == ' ' || r == '-' }) {
+		switch upper := strings.ToUpper(word); upper {
+		case "ID", "URL", "API", "UUID", "HTTP", "IP", "JSON", "SQL":
+			b.WriteString(upper)
+		default:
+			runes := []rune(word)
+			runes[0] = unicode.ToUpper(runes[0])
+			b.WriteString(string(runes))
+		}
+	}
+	return b.String()
+}
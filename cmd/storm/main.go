@@ -0,0 +1,140 @@
+// Command storm manages the database of a storm application: it runs the SQL migrations,
+// creates new ones, and generates model structs from the existing tables.
+//
+//	storm migrate up|down|status   apply, revert or list the migrations
+//	storm create migration <name>  create the up and down files of a new migration
+//	storm gen models               write a Go struct for every table
+//
+// The database is given with -dsn, or the STORM_DSN environment variable.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/lib/pq"
+	"github.com/pepega90/storm"
+	"github.com/pepega90/storm/migrate"
+)
+
+const usage = `usage: storm [flags] <command>
+
+commands:
+  migrate up               apply every pending migration
+  migrate down [-steps n]  revert the last n applied migrations (default 1)
+  migrate status           list the migrations and whether they are applied
+  create migration <name>  create the up and down files of a new migration
+  gen models               generate a Go struct for every table of the database
+
+flags:
+`
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	driver := flag.String("driver", "postgres", "database/sql driver name")
+	dsn := flag.String("dsn", os.Getenv("STORM_DSN"), "data source name, defaults to $STORM_DSN")
+	dir := flag.String("dir", "migrations", "directory of the migration files")
+	out := flag.String("out", "models", "output directory of gen models")
+	pkg := flag.String("package", "models", "package name of the generated models")
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] + " " + args[1] {
+	case "create migration":
+		if len(args) < 3 {
+			err = fmt.Errorf("create migration needs a name")
+			break
+		}
+		var up, down string
+		up, down, err = migrate.Create(*dir, args[2])
+		if err == nil {
+			fmt.Println("created", up)
+			fmt.Println("created", down)
+		}
+
+	case "migrate up", "migrate down", "migrate status":
+		err = withDB(*driver, *dsn, func(db *storm.Storm) error {
+			return runMigrate(migrate.New(db, *dir), args[1], args[2:])
+		})
+
+	case "gen models":
+		err = withDB(*driver, *dsn, func(db *storm.Storm) error {
+			return genModels(db, *out, *pkg)
+		})
+
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "storm:", err)
+		os.Exit(1)
+	}
+}
+
+// withDB connects to the database and calls fn with it.
+func withDB(driver, dsn string, fn func(db *storm.Storm) error) error {
+	if dsn == "" {
+		return fmt.Errorf("no database, set -dsn or STORM_DSN")
+	}
+	db, err := storm.New(driver, dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return fn(db)
+}
+
+// runMigrate runs the migrate subcommand cmd (up, down or status) with its args.
+func runMigrate(m *migrate.Migrator, cmd string, args []string) error {
+	switch cmd {
+	case "up":
+		done, err := m.Up()
+		for _, mig := range done {
+			fmt.Printf("applied  %s_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("nothing to apply")
+		}
+		return err
+
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ExitOnError)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+		flags.Parse(args)
+
+		done, err := m.Down(*steps)
+		for _, mig := range done {
+			fmt.Printf("reverted %s_%s\n", mig.Version, mig.Name)
+		}
+		if err == nil && len(done) == 0 {
+			fmt.Println("nothing to revert")
+		}
+		return err
+
+	default:
+		status, err := m.Status()
+		if err != nil {
+			return err
+		}
+		for _, st := range status {
+			state := "pending"
+			if st.Applied {
+				state = "applied " + st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-16s %-40s %s\n", st.Version, st.Name, state)
+		}
+		return nil
+	}
+}
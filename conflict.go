@@ -0,0 +1,110 @@
+package storm
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RetryOnConflict calls fn until it succeeds or returns an error other than ErrStaleObject and
+// ErrDuplicateKey, at most attempts times, waiting a little longer (with random jitter) before
+// every new attempt. fn must reload what it changes, so the next attempt works on fresh rows:
+//
+//	err := storm.RetryOnConflict(3, func() error {
+//		var account Account
+//		if err := db.Get(&account, id); err != nil {
+//			return err
+//		}
+//		account.Balance += amount
+//		_, err := db.Update(&account) // ErrStaleObject when someone updated it in between
+//		return err
+//	})
+//
+// The error of the last attempt is returned.
+func RetryOnConflict(attempts int, fn func() error) error {
+	backoff := 10 * time.Millisecond
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			// full jitter, so the transactions that conflicted don't all come back at the same time
+			time.Sleep(time.Duration(rand.Int63n(int64(backoff))))
+			if backoff < time.Second {
+				backoff *= 2
+			}
+		}
+
+		err = fn()
+		if !errors.Is(err, ErrStaleObject) && !errors.Is(err, ErrDuplicateKey) {
+			return err
+		}
+	}
+	return err
+}
+
+// translateError turns the errors of the driver storm knows about into its own errors,
+// like a unique violation into ErrDuplicateKey or a NOWAIT lock conflict into ErrLocked,
+// other errors are returned as they are.
+func translateError(err error) error {
+	if err == nil || errors.Is(err, ErrDuplicateKey) || errors.Is(err, ErrLocked) {
+		return err
+	}
+	if isDuplicateKey(err) {
+		return fmt.Errorf("%w: %w", ErrDuplicateKey, err)
+	}
+	if isLockNotAvailable(err) {
+		return fmt.Errorf("%w: %w", ErrLocked, err)
+	}
+	return err
+}
+
+// isDuplicateKey reports whether err is a unique violation of postgres (lib/pq or a driver
+// exposing the SQLSTATE), mysql or sqlite.
+func isDuplicateKey(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "23505"
+	}
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		return state.SQLState() == "23505"
+	}
+
+	// the other drivers are not dependencies of storm, so we recognize their messages
+	msg := err.Error()
+	return strings.Contains(msg, "Error 1062") || strings.Contains(msg, "UNIQUE constraint failed")
+}
+
+// isLockNotAvailable reports whether err is the error of a NOWAIT lock that another
+// transaction holds.
+func isLockNotAvailable(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return pqErr.Code == "55P03"
+	}
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) {
+		return state.SQLState() == "55P03"
+	}
+	return strings.Contains(err.Error(), "Error 3572")
+}
+
+// isVersion reports whether field is the version of the model, tagged with version.
+func (f *SchemaField) isVersion() bool {
+	_, ok := f.Tag["version"]
+	return ok
+}
+
+// incrementVersion adds one to v, the version field of a model that was just updated.
+func incrementVersion(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(v.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(v.Uint() + 1)
+	}
+}
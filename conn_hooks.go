@@ -0,0 +1,209 @@
+package storm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// Conn is a single connection of the pool, given to the OnConnect and OnDisconnect hooks.
+type Conn struct {
+	conn driver.Conn
+}
+
+// Exec executes query on the connection, its placeholders are the ones of the driver,
+// like $1 for postgres and ? for mysql.
+func (c *Conn) Exec(ctx context.Context, query string, args ...interface{}) error {
+	named := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		value, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return err
+		}
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: value}
+	}
+
+	if execer, ok := c.conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, named)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+
+	// the driver can't execute directly, we prepare the statement
+	stmt, err := c.conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	if execer, ok := stmt.(driver.StmtExecContext); ok {
+		_, err = execer.ExecContext(ctx, named)
+		return err
+	}
+	values := make([]driver.Value, len(named))
+	for i, v := range named {
+		values[i] = v.Value
+	}
+	_, err = stmt.Exec(values) // drivers without StmtExecContext only have Exec
+	return err
+}
+
+// OnConnect registers fn to run on every new connection of the pool, before it is used,
+// the place for session settings and extensions:
+//
+//	storm.New("postgres", dsn, storm.OnConnect(func(ctx context.Context, c *storm.Conn) error {
+//		return c.Exec(ctx, "SET application_name = 'api'")
+//	}))
+//
+// If fn returns an error the connection is closed and the statement that needed it fails.
+// The hooks are installed by New, NewWithDB can't add them to a pool it didn't open.
+func OnConnect(fn func(ctx context.Context, c *Conn) error) Option {
+	return func(s *Storm) {
+		s.connectHooks = append(s.connectHooks, fn)
+	}
+}
+
+// OnDisconnect registers fn to run when a connection of the pool is about to be closed,
+// because the pool doesn't need it anymore or it expired (see ConnPool), see OnConnect.
+func OnDisconnect(fn func(c *Conn)) Option {
+	return func(s *Storm) {
+		s.disconnectHooks = append(s.disconnectHooks, fn)
+	}
+}
+
+// hookConnector returns the connector of drv for dsn, running the connection hooks of s.
+func (s *Storm) hookConnector(drv driver.Driver, dsn string) (driver.Connector, error) {
+	c := &hookConnector{storm: s, driver: drv, dsn: dsn}
+	if dc, ok := drv.(driver.DriverContext); ok {
+		connector, err := dc.OpenConnector(dsn)
+		if err != nil {
+			return nil, err
+		}
+		c.connector = connector
+	}
+	return c, nil
+}
+
+// hookConnector, opens the connections with the driver and runs the OnConnect hooks on them
+type hookConnector struct {
+	storm     *Storm
+	driver    driver.Driver
+	connector driver.Connector // connector, of the driver, nil when it only supports Open
+	dsn       string
+}
+
+// Connect implements driver.Connector.
+func (c *hookConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var conn driver.Conn
+	var err error
+	if c.connector != nil {
+		conn, err = c.connector.Connect(ctx)
+	} else {
+		conn, err = c.driver.Open(c.dsn)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, fn := range c.storm.connectHooks {
+		if err := fn(ctx, &Conn{conn: conn}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("storm: on connect: %w", err)
+		}
+	}
+	return &hookConn{Conn: conn, storm: c.storm}, nil
+}
+
+// Driver implements driver.Connector.
+func (c *hookConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// hookConn, is a connection of the driver that runs the OnDisconnect hooks when it is closed.
+// It forwards the optional interfaces of the driver connection, so database/sql uses it
+// like the connection itself.
+type hookConn struct {
+	driver.Conn
+	storm *Storm
+}
+
+// Close runs the OnDisconnect hooks and closes the connection.
+func (c *hookConn) Close() error {
+	for _, fn := range c.storm.disconnectHooks {
+		fn(&Conn{conn: c.Conn})
+	}
+	return c.Conn.Close()
+}
+
+// BeginTx implements driver.ConnBeginTx. Drivers without ConnBeginTx only have Begin, which
+// can't honor an isolation level or a read-only transaction, so those are an error like
+// database/sql gives for them, rather than a transaction silently started without them.
+func (c *hookConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	if opts.Isolation != driver.IsolationLevel(sql.LevelDefault) {
+		return nil, fmt.Errorf("storm: the driver does not support non-default isolation levels")
+	}
+	if opts.ReadOnly {
+		return nil, fmt.Errorf("storm: the driver does not support read-only transactions")
+	}
+	return c.Conn.Begin()
+}
+
+// PrepareContext implements driver.ConnPrepareContext.
+func (c *hookConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+// ExecContext implements driver.ExecerContext.
+func (c *hookConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if e, ok := c.Conn.(driver.ExecerContext); ok {
+		return e.ExecContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// QueryContext implements driver.QueryerContext.
+func (c *hookConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if q, ok := c.Conn.(driver.QueryerContext); ok {
+		return q.QueryContext(ctx, query, args)
+	}
+	return nil, driver.ErrSkip
+}
+
+// Ping implements driver.Pinger.
+func (c *hookConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// ResetSession implements driver.SessionResetter.
+func (c *hookConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+// IsValid implements driver.Validator.
+func (c *hookConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+// CheckNamedValue implements driver.NamedValueChecker.
+func (c *hookConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
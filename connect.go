@@ -0,0 +1,69 @@
+package storm
+
+import (
+	"context"
+	"time"
+)
+
+// ConnectRetry configures how New retries to reach a database that is not up yet, see WithConnectRetry.
+type ConnectRetry struct {
+	Timeout    time.Duration                // Timeout, give up after it, default 30 seconds
+	Backoff    time.Duration                // Backoff, wait after the first failure, doubled after every other one, default 100ms
+	MaxBackoff time.Duration                // MaxBackoff, longest wait between two attempts, default 5 seconds
+	OnRetry    func(attempt int, err error) // OnRetry, optional, called after every failed attempt, like for logging
+}
+
+// WithConnectRetry makes New retry its Ping with exponential backoff until it succeeds or
+// retry.Timeout expires, instead of failing right away. It is made for containers started
+// together with their database:
+//
+//	db, err := storm.New("postgres", dsn, storm.WithConnectRetry(storm.ConnectRetry{
+//		Timeout: time.Minute,
+//		OnRetry: func(attempt int, err error) { log.Printf("database not ready (%d): %v", attempt, err) },
+//	}))
+//
+// NewWithDB doesn't ping, so it ignores this option.
+func WithConnectRetry(retry ConnectRetry) Option {
+	return func(s *Storm) {
+		if retry.Timeout <= 0 {
+			retry.Timeout = 30 * time.Second
+		}
+		if retry.Backoff <= 0 {
+			retry.Backoff = 100 * time.Millisecond
+		}
+		if retry.MaxBackoff <= 0 {
+			retry.MaxBackoff = 5 * time.Second
+		}
+		s.connectRetry = &retry
+	}
+}
+
+// ping checks the connection to the database, retrying it as configured by WithConnectRetry.
+// The error of the last attempt is returned.
+func (s *Storm) ping() error {
+	retry := s.connectRetry
+	if retry == nil {
+		return s.db.Ping()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), retry.Timeout)
+	defer cancel()
+
+	backoff := retry.Backoff
+	for attempt := 1; ; attempt++ {
+		err := s.db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		if retry.OnRetry != nil {
+			retry.OnRetry(attempt, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, retry.MaxBackoff)
+	}
+}
@@ -0,0 +1,72 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// CountDistinct returns the number of distinct non NULL values of column among the rows of the
+// query. It shares the joins and the conditions of the query (the global scopes and the soft
+// deletes too), the ordering, limit and offset don't change a count so they are left out:
+//
+//	buyers, err := db.Table("orders").Where("created_at >= $1", from).CountDistinct("user_id")
+//	// SELECT COUNT(DISTINCT user_id) FROM orders WHERE created_at >= $1
+//
+// Use CountOver for a query with GROUP BY, which has one count per group.
+func (q *Query) CountDistinct(column string) (int, error) {
+	if !isIdentifier(column) {
+		return 0, fmt.Errorf("storm: invalid column name %q in CountDistinct", column)
+	}
+	return q.count(fmt.Sprintf("COUNT(DISTINCT %s)", column), 0)
+}
+
+// CountOver returns the number of rows the query returns without its limit and offset, counted
+// with the window function COUNT(*) OVER (), so a grouped query counts its groups:
+//
+//	n, err := db.Table("orders").GroupBy("user_id").HavingCount(">", 5).CountOver()
+//	// SELECT COUNT(*) OVER () FROM orders GROUP BY user_id HAVING COUNT(*) > $1 LIMIT $2
+//
+// It is the total Paginate uses. mysql needs 8.0 and sqlite 3.25 for window functions.
+func (q *Query) CountOver() (int, error) {
+	return q.count("COUNT(*) OVER ()", 1)
+}
+
+// total returns the number of rows of the query without its limit and offset, for the pages:
+// a plain COUNT(*) when it is not grouped, CountOver otherwise.
+func (q *Query) total() (int, error) {
+	if len(q.groupBy) > 0 {
+		return q.CountOver()
+	}
+	return q.count("COUNT(*)", 0)
+}
+
+// count selects the aggregate expr with the joins and the conditions of the query, and returns
+// the value of the first row, 0 when there is none.
+func (q *Query) count(expr string, limit int) (int, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	if q.raw != "" {
+		return 0, fmt.Errorf("storm: counting is not supported for named queries")
+	}
+
+	counter := *q
+	counter.extra = nil
+	counter.orders = nil
+	counter.offset = 0
+	// FOR UPDATE is refused with an aggregate, and counting doesn't need the rows locked
+	counter.lock = ""
+	query, args, err := counter.buildSelect([]string{expr}, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int
+	err = q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		if rows.Next() {
+			return rows.Scan(&n)
+		}
+		return nil
+	})
+	return n, err
+}
@@ -0,0 +1,104 @@
+package storm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DeleteBatched deletes the rows matching the query in batches of batchSize rows, one statement
+// per batch, until no matching row remains, and returns how many rows were deleted. Every batch
+// only locks the rows it deletes, so retention jobs don't lock the whole table or build one huge
+// transaction:
+//
+//	deleted, err := db.From(&Event{}).Where("created_at < $1", cutoff).DeleteBatched(10000)
+//
+// The batches are picked by primary key when the query has a model with one, otherwise by ctid
+// on postgres and rowid on sqlite, mysql uses DELETE ... LIMIT. Like DeleteFrom it refuses to run
+// without a WHERE condition (ErrMissingWhereClause). Outside a transaction every batch is
+// committed on its own, an error stops the purge with the batches before it deleted.
+// The rows of a SoftDeletable model are marked as deleted in batches, like Delete, unless the
+// session is Unscoped, which purges them for real.
+func (q *Query) DeleteBatched(batchSize int) (int64, error) {
+	if q.err != nil {
+		return 0, q.err
+	}
+	if q.raw != "" {
+		return 0, fmt.Errorf("storm: DeleteBatched is not supported for named queries")
+	}
+	if batchSize <= 0 {
+		return 0, fmt.Errorf("storm: DeleteBatched needs a positive batch size, got %d", batchSize)
+	}
+
+	where, _, err := q.where.build(0)
+	if err != nil {
+		return 0, err
+	}
+	if where == "" {
+		return 0, ErrMissingWhereClause
+	}
+
+	action := "DELETE FROM " + q.table
+	set := newParams()
+	batches := q
+	if soft, ok := q.model.(SoftDeletable); ok && !q.storm.unscoped {
+		marker := soft.SoftDelete()
+		if len(marker) == 0 {
+			return 0, fmt.Errorf("storm: SoftDelete of %s returns no column", q.table)
+		}
+		if soft.NotDeleted() == nil {
+			return 0, fmt.Errorf("storm: DeleteBatched can't soft delete %s, NotDeleted returns no condition to leave the marked rows out", q.table)
+		}
+		assignments := make([]string, 0, len(marker))
+		for _, col := range sortedKeys(marker) {
+			assignments = append(assignments, fmt.Sprintf("%s = %s", col, set.add(marker[col])))
+		}
+		action = fmt.Sprintf("UPDATE %s SET %s", q.table, strings.Join(assignments, ", "))
+
+		// the marked rows leave the next batches, even for a query WithDeleted
+		notDeleted := *q
+		notDeleted.withDeleted = false
+		batches = &notDeleted
+	}
+
+	where, args, err := batches.conditions(q.where).build(len(set.args))
+	if err != nil {
+		return 0, err
+	}
+
+	// the limit is the placeholder after the ones of the assignments and of the conditions
+	bound := &params{args: append(set.args, args...)}
+	limit := bound.add(batchSize)
+
+	var stmt string
+	if dialect := q.storm.dialect.Name(); dialect == "mysql" {
+		stmt = fmt.Sprintf("%s WHERE %s LIMIT %s", action, where, limit)
+	} else {
+		key := "ctid"
+		if dialect == "sqlite3" {
+			key = "rowid"
+		}
+		if q.model != nil {
+			if info, err := q.storm.schema.parseModel(q.model); err == nil && info.PK != nil {
+				key = info.PK.Column
+			}
+		}
+		stmt = fmt.Sprintf("%s WHERE %s IN (SELECT %s FROM %s WHERE %s LIMIT %s)", action, key, key, q.table, where, limit)
+	}
+
+	var total int64
+	for {
+		res, err := q.storm.exec(stmt, bound.args...)
+		if err != nil {
+			return total, err
+		}
+		q.storm.forgetCachedTable(q.table)
+		deleted, err := res.RowsAffected()
+		if err != nil {
+			return total, err
+		}
+		total += deleted
+		if deleted < int64(batchSize) {
+			return total, nil
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package storm_test
+
+import (
+	"testing"
+
+	"github.com/pepega90/storm"
+)
+
+func TestDeleteReturning(t *testing.T) {
+	db := newDryRun(t)
+	db.RegisterScope("tenant", tenantScope)
+	var deleted []invoice
+
+	// a SoftDeletable model is marked as deleted, like Delete does
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.From(&invoice{}).Where("status = ?", "void").DeleteReturning(&deleted)
+	}, storm.Statement{
+		SQL:  "UPDATE invoices SET deleted_at = $1 WHERE (status = $2) AND (deleted_at IS NULL) AND (tenantid = $3) RETURNING *",
+		Args: []interface{}{anyArg{}, "void", 7},
+	})
+
+	checkSQL(t, db.Unscoped(), func(tx *storm.Storm) error {
+		return tx.From(&invoice{}).Where("status = ?", "void").DeleteReturning(&deleted, "id")
+	}, storm.Statement{
+		SQL:  "DELETE FROM invoices WHERE status = $1 RETURNING id",
+		Args: []interface{}{"void"},
+	})
+
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.From(&user{}).Where("name = ?", "a").DeleteReturning(&[]user{})
+	}, storm.Statement{
+		SQL:  "DELETE FROM users WHERE (name = $1) AND (tenantid = $2) RETURNING *",
+		Args: []interface{}{"a", 7},
+	})
+}
+
+func TestDeleteBatched(t *testing.T) {
+	db := newDryRun(t)
+	db.RegisterScope("tenant", tenantScope)
+
+	// a SoftDeletable model is marked as deleted batch after batch, even WithDeleted
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		_, err := tx.From(&invoice{}).WithDeleted().Where("status = ?", "void").DeleteBatched(100)
+		return err
+	}, storm.Statement{
+		SQL:  "UPDATE invoices SET deleted_at = $1 WHERE id IN (SELECT id FROM invoices WHERE (status = $2) AND (deleted_at IS NULL) AND (tenantid = $3) LIMIT $4)",
+		Args: []interface{}{anyArg{}, "void", 7, 100},
+	})
+
+	checkSQL(t, db.Unscoped(), func(tx *storm.Storm) error {
+		_, err := tx.From(&invoice{}).Where("status = ?", "void").DeleteBatched(100)
+		return err
+	}, storm.Statement{
+		SQL:  "DELETE FROM invoices WHERE id IN (SELECT id FROM invoices WHERE status = $1 LIMIT $2)",
+		Args: []interface{}{"void", 100},
+	})
+}
+
+func TestDelete(t *testing.T) {
+	db := newDryRun(t)
+	db.RegisterScope("tenant", tenantScope)
+
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		_, err := tx.Delete(&user{ID: 3})
+		return err
+	}, storm.Statement{
+		SQL:  "DELETE FROM users WHERE (id = $1) AND (tenantid = $2)",
+		Args: []interface{}{3, 7},
+	})
+
+	// a SoftDeletable model is only marked as deleted, if it isn't already
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		_, err := tx.Delete(&invoice{ID: 3})
+		return err
+	}, storm.Statement{
+		SQL:  "UPDATE invoices SET deleted_at = $1 WHERE (id = $2) AND (deleted_at IS NULL) AND (tenantid = $3)",
+		Args: []interface{}{anyArg{}, 3, 7},
+	})
+
+	checkSQL(t, db.Unscoped(), func(tx *storm.Storm) error {
+		_, err := tx.Delete(&invoice{ID: 3})
+		return err
+	}, storm.Statement{
+		SQL:  "DELETE FROM invoices WHERE id = $1",
+		Args: []interface{}{3},
+	})
+
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		_, err := tx.DeleteWhere(&invoice{}, "status = ?", "void")
+		return err
+	}, storm.Statement{
+		SQL:  "UPDATE invoices SET deleted_at = $1 WHERE (status = $2) AND (deleted_at IS NULL) AND (tenantid = $3)",
+		Args: []interface{}{anyArg{}, "void", 7},
+	})
+
+	if _, err := db.DeleteWhere(&user{}, ""); err != storm.ErrMissingWhereClause {
+		t.Errorf("got %v, want ErrMissingWhereClause", err)
+	}
+}
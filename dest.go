@@ -0,0 +1,38 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// checkStruct returns an error unless v, a model or destination given by the application,
+// is a non-nil pointer to a struct, so a wrong argument is reported instead of panicking in reflect.
+// what names v in the error, like "dest" or "model".
+func checkStruct(what string, v interface{}) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("storm: %s must be a non-nil pointer to a struct, got %s", what, describe(v))
+	}
+	return nil
+}
+
+// checkSlice returns an error unless dest is a non-nil pointer to a slice of structs, like *[]User.
+func checkSlice(dest interface{}) error {
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Slice || val.Elem().Type().Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("storm: dest must be a pointer to a slice of structs, like *[]User, got %s", describe(dest))
+	}
+	return nil
+}
+
+// describe returns the type of v for an error, telling nil and nil pointers apart.
+func describe(v interface{}) string {
+	val := reflect.ValueOf(v)
+	switch {
+	case !val.IsValid():
+		return "nil"
+	case val.Kind() == reflect.Ptr && val.IsNil():
+		return fmt.Sprintf("nil %T", v)
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,138 @@
+package storm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Dialect describes the SQL differences between databases that storm needs to know about
+// when it generates statements.
+type Dialect interface {
+	// Name returns the name of the dialect, for example "postgres".
+	Name() string
+	// Placeholder returns the bind parameter for the n-th (1-based) argument of a statement,
+	// for example "$1" in PostgreSQL or "?" in MySQL.
+	Placeholder(n int) string
+}
+
+// postgresDialect, is the dialect for PostgreSQL, it use numbered placeholder like $1, $2
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string             { return "postgres" }
+func (postgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+// mysqlDialect, is the dialect for MySQL, it use positional placeholder ?
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string           { return "mysql" }
+func (mysqlDialect) Placeholder(int) string { return "?" }
+
+// sqliteDialect, is the dialect for SQLite, it use positional placeholder ?
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string           { return "sqlite3" }
+func (sqliteDialect) Placeholder(int) string { return "?" }
+
+// dialectFor returns the dialect that match the database/sql driver name.
+// Unknown drivers fall back to PostgreSQL, since that is what storm supports first.
+func dialectFor(driverName string) Dialect {
+	switch driverName {
+	case "mysql":
+		return mysqlDialect{}
+	case "sqlite", "sqlite3":
+		return sqliteDialect{}
+	default:
+		return postgresDialect{}
+	}
+}
+
+// params collects the arguments of a statement and hands out a sequential placeholder for each of them,
+// so the numbering never has gaps no matter which fields we skip while building the SQL.
+// Statements are always built with numbered placeholders ($1, $2, ...), rebind converts them
+// into the placeholder style of the dialect right before the statement is executed.
+type params struct {
+	args []interface{}
+}
+
+// newParams creates empty params.
+func newParams() *params {
+	return &params{}
+}
+
+// add appends v to the arguments and returns the placeholder that bind it.
+func (p *params) add(v interface{}) string {
+	p.args = append(p.args, v)
+	return "$" + strconv.Itoa(len(p.args))
+}
+
+// rebind converts the numbered placeholders of query into the placeholder style of d.
+// For dialect with positional placeholder (?) the arguments are reordered to follow
+// the order the placeholders appear in the query.
+func rebind(d Dialect, query string, args []interface{}) (string, []interface{}) {
+	if d.Placeholder(1) == "$1" || !strings.Contains(query, "$") {
+		return query, args
+	}
+
+	var b strings.Builder
+	var newArgs []interface{}
+	inQuote := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		if c == '\'' {
+			inQuote = !inQuote
+		}
+		if c != '$' || inQuote || i+1 >= len(query) || !isDigit(query[i+1]) {
+			b.WriteByte(c)
+			continue
+		}
+
+		j := i + 1
+		for j < len(query) && isDigit(query[j]) {
+			j++
+		}
+		n, _ := strconv.Atoi(query[i+1 : j])
+		if n >= 1 && n <= len(args) {
+			newArgs = append(newArgs, args[n-1])
+		}
+		b.WriteString(d.Placeholder(len(newArgs)))
+		i = j - 1
+	}
+	return b.String(), newArgs
+}
+
+// shiftPlaceholders renumbers the numbered placeholders ($1, $2, ...) of a hand written SQL fragment by offset,
+// so a fragment like "id = $1" can be placed after offset other arguments and becomes "id = $3" (offset 2).
+// Placeholders inside quoted strings are left alone. Dialects with positional placeholder (?) don't need this,
+// since only the order of the arguments matters there.
+func shiftPlaceholders(fragment string, offset int) string {
+	if offset == 0 || !strings.Contains(fragment, "$") {
+		return fragment
+	}
+
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(fragment); i++ {
+		c := fragment[i]
+		if c == '\'' {
+			inQuote = !inQuote
+		}
+		if c != '$' || inQuote || i+1 >= len(fragment) || !isDigit(fragment[i+1]) {
+			b.WriteByte(c)
+			continue
+		}
+
+		// read the whole number after $
+		j := i + 1
+		for j < len(fragment) && isDigit(fragment[j]) {
+			j++
+		}
+		n, _ := strconv.Atoi(fragment[i+1 : j])
+		b.WriteString("$" + strconv.Itoa(n+offset))
+		i = j - 1
+	}
+	return b.String()
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
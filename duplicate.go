@@ -0,0 +1,147 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Duplicate copies the row of src, a model with its primary key set, into a new row with a
+// single INSERT ... SELECT, so the values never travel through the application, and loads the
+// new row into dst, a pointer to the same model. overrides sets columns of the copy instead of
+// copying them, handy for "clone this template" features:
+//
+//	var copy Invoice
+//	err := db.Duplicate(&template, &copy, map[string]interface{}{
+//		"status":     "draft",
+//		"created_at": time.Now(),
+//	})
+//
+// The auto increment key of the copy is generated by the database, other keys (like UUIDs)
+// must be given in overrides. Generated and read only fields are computed again. The row of src is read as it
+// is in the database, not from src, through the global scopes and the soft deletes like a query.
+// The BeforeInsert hooks are called on dst first: the non-zero fields of dst, like the ones they
+// fill, are written instead of copied, overrides win over them.
+func (s *Storm) Duplicate(src interface{}, dst interface{}, overrides map[string]interface{}) error {
+	info, err := s.schema.parseModel(src)
+	if err != nil {
+		return err
+	}
+	if err := checkStruct("src", src); err != nil {
+		return err
+	}
+	if info.PK == nil {
+		return fmt.Errorf("storm: %s has no primary key", info.Type.Name())
+	}
+	if dstInfo, err := s.schema.parseModel(dst); err != nil || dstInfo != info {
+		return fmt.Errorf("storm: Duplicate needs dst of the type of src %s, got %T", info.Type.Name(), dst)
+	}
+	pkValue := info.PK.Value(reflect.ValueOf(src).Elem())
+	if pkValue.IsZero() {
+		return fmt.Errorf("storm: Duplicate needs the primary key of src")
+	}
+	if err := s.callHook(HookBeforeInsert, dst); err != nil {
+		return err
+	}
+	val := reflect.ValueOf(dst).Elem()
+
+	byColumn := map[string]*SchemaField{}
+	for _, field := range info.Fields {
+		byColumn[field.Column] = field
+	}
+	for col := range overrides {
+		if _, ok := byColumn[col]; !ok {
+			return fmt.Errorf("storm: %s has no column %s", info.Type.Name(), col)
+		}
+	}
+
+	// columns, of the new row, selected is the value of each one: the column of the copied
+	// row itself, or the placeholder of its override
+	var columns, selected []string
+	args := newParams()
+	for _, field := range info.Fields {
+		if !field.writable() {
+			continue
+		}
+		override, ok := overrides[field.Column]
+		switch {
+		case ok:
+			if override != nil {
+				if override, err = s.writeValue(field, reflect.ValueOf(override)); err != nil {
+					return err
+				}
+			}
+			selected = append(selected, args.add(override))
+		// a field filled on dst, like the UUID key of a BeforeInsert hook, the auto increment key
+		// is always generated again
+		case !field.autoIncrement() && !field.Value(val).IsZero():
+			value, err := s.writeValue(field, field.Value(val))
+			if err != nil {
+				return err
+			}
+			selected = append(selected, args.add(value))
+		case field.autoIncrement():
+			continue
+		default:
+			selected = append(selected, field.Column)
+		}
+		columns = append(columns, field.Column)
+	}
+
+	// the row is read like a query, so a scoped session only copies the rows it can see
+	table := s.tableName(info.Table)
+	pkWhere := whereClause{rawExpr{fmt.Sprintf("%s = $1", info.PK.Column), []interface{}{pkValue.Interface()}}}
+	where, whereArgs, err := s.From(src).conditions(pkWhere).build(len(args.args))
+	if err != nil {
+		return err
+	}
+	args.args = append(args.args, whereArgs...)
+	q := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE %s",
+		table,
+		strings.Join(columns, ", "),
+		strings.Join(selected, ", "),
+		table,
+		where,
+	)
+
+	// mysql has no RETURNING, we load the copy with its new key
+	if s.dialect.Name() == "mysql" {
+		res, err := s.exec(q, args.args...)
+		if err != nil {
+			return err
+		}
+		id, ok := overrides[info.PK.Column]
+		if !ok {
+			if id, err = res.LastInsertId(); err != nil {
+				return err
+			}
+		}
+		return s.Get(dst, id)
+	}
+
+	found := false
+	query := s.From(dst)
+	err = s.queryRows(q+" RETURNING *", args.args, func(rows *sql.Rows) error {
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		if !rows.Next() {
+			return nil
+		}
+		vals, err := scanValues(rows, len(cols))
+		if err != nil {
+			return err
+		}
+		found = true
+		return query.mapRow(val, cols, vals, query.fieldsFor(val.Type(), cols), false)
+	})
+	if err != nil {
+		return err
+	}
+	if !found && !s.dryRun {
+		return ErrRecordNotFound
+	}
+	return s.callHook(HookAfterInsert, dst)
+}
@@ -0,0 +1,40 @@
+package storm_test
+
+import (
+	"testing"
+
+	"github.com/pepega90/storm"
+)
+
+type invoice struct {
+	ID       int `storm:"pk"`
+	Status   string
+	TenantID int
+	storm.SoftDeleteAt
+}
+
+// BeforeInsert marks every new invoice, copies included, as a draft.
+func (i *invoice) BeforeInsert(s *storm.Storm) error {
+	i.Status = "draft"
+	return nil
+}
+
+func TestDuplicateScoped(t *testing.T) {
+	db := newDryRun(t)
+	db.RegisterScope("tenant", tenantScope)
+
+	// the copied row is read through the scopes and the soft deletes, the hook fills status
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.Duplicate(&invoice{ID: 3}, &invoice{}, map[string]interface{}{"tenantid": 8})
+	}, storm.Statement{
+		SQL:  "INSERT INTO invoices (status, tenantid, deleted_at) SELECT $1, $2, deleted_at FROM invoices WHERE (id = $3) AND (deleted_at IS NULL) AND (tenantid = $4) RETURNING *",
+		Args: []interface{}{"draft", 8, 3, 7},
+	})
+
+	checkSQL(t, db.Unscoped(), func(tx *storm.Storm) error {
+		return tx.Duplicate(&invoice{ID: 3}, &invoice{}, nil)
+	}, storm.Statement{
+		SQL:  "INSERT INTO invoices (status, tenantid, deleted_at) SELECT $1, tenantid, deleted_at FROM invoices WHERE id = $2 RETURNING *",
+		Args: []interface{}{"draft", 3},
+	})
+}
@@ -0,0 +1,110 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// rawBytesType is the type of sql.RawBytes fields, scanned without copy by Each.
+var rawBytesType = reflect.TypeOf(sql.RawBytes(nil))
+
+// Each runs the query and streams its rows: every row is mapped into dest, a pointer to struct,
+// then fn is called, so a big result set is never loaded in memory. dest is reset before every row.
+//
+// Fields of type sql.RawBytes are scanned without any copy, they point into the memory of the
+// driver and are only valid until fn returns, so very large text or blob columns can be processed
+// without allocating them again and again. Copy what you need to keep:
+//
+//	type Attachment struct {
+//		ID      int `storm:"pk"`
+//		Name    string
+//		Content sql.RawBytes
+//	}
+//
+//	var a Attachment
+//	err := db.From(&Attachment{}).Each(&a, func() error {
+//		_, err := archive.Write(a.Content)
+//		return err
+//	})
+//
+// Other []byte fields are copied like Select does. An error returned by fn stops the iteration
+// and is returned by Each.
+func (q *Query) Each(dest interface{}, fn func() error, queryCol ...string) error {
+	if q.err != nil {
+		return q.err
+	}
+	val := reflect.ValueOf(dest)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("storm: Each needs a pointer to struct, got %T", dest)
+	}
+	row := val.Elem()
+
+	queryCol, err := q.selectColumns(queryCol, row.Type())
+	if err != nil {
+		return err
+	}
+
+	query, args, err := q.buildSelect(queryCol, q.limit)
+	if err != nil {
+		return err
+	}
+
+	return q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		fields := q.fieldsFor(row.Type(), cols)
+
+		// the columns of sql.RawBytes fields are scanned into raws, the others into vals,
+		// the scan targets are the same for every row
+		vals := make([]interface{}, len(cols))
+		raws := make([]sql.RawBytes, len(cols))
+		isRaw := make([]bool, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i, col := range cols {
+			ptrs[i] = &vals[i]
+			if field, ok := fields[col]; ok && scanRaw(field) {
+				ptrs[i] = &raws[i]
+				isRaw[i] = true
+			}
+		}
+
+		for rows.Next() {
+			if err := rows.Scan(ptrs...); err != nil {
+				return err
+			}
+			for i := range cols {
+				if isRaw[i] {
+					vals[i] = nil
+					if raws[i] != nil {
+						vals[i] = raws[i]
+					}
+				}
+			}
+
+			row.Set(reflect.Zero(row.Type()))
+			if err := q.mapRow(row, cols, vals, fields, len(queryCol) == 0); err != nil {
+				return err
+			}
+			if err := q.storm.callHook(HookAfterFind, dest); err != nil {
+				return err
+			}
+			if err := fn(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// scanRaw reports whether field is scanned as sql.RawBytes, without copy. Encrypted and
+// masked fields are changed after they are read, so they are copied.
+func scanRaw(field *SchemaField) bool {
+	if field.Type != rawBytesType || field.isEncrypted() {
+		return false
+	}
+	_, masked := field.Tag["mask"]
+	return !masked
+}
@@ -0,0 +1,153 @@
+package storm
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+)
+
+// WithEncryption sets the key fields tagged `storm:"encrypt"` are encrypted with.
+// Their values are sealed with aead before Insert, Update and Upsert, and opened when rows are
+// mapped into structs, so the application only sees plain text while the database only
+// stores ciphertext:
+//
+//	type User struct {
+//		ID    int    `storm:"pk"`
+//		Email string `storm:"encrypt"`
+//	}
+//
+// Encrypted fields must be string (stored base64 encoded, in a text column) or []byte
+// (stored as it is, in a binary column). Every write uses a random nonce, so the same
+// value never gives the same ciphertext, which means WHERE conditions on an encrypted
+// column can't match, look rows up by another column.
+// Use NewAESGCM to create aead from a key.
+func WithEncryption(aead cipher.AEAD) Option {
+	return func(s *Storm) {
+		s.aead = aead
+	}
+}
+
+// NewAESGCM returns an AES-GCM AEAD for key, which must be 16, 24 or 32 bytes long
+// (AES-128, AES-192 or AES-256).
+func NewAESGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("storm: encryption key: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// isEncrypted reports whether field is tagged with encrypt.
+func (f *SchemaField) isEncrypted() bool {
+	_, ok := f.Tag["encrypt"]
+	return ok
+}
+
+// hasEncrypted reports whether any field of info is tagged with encrypt.
+func (info *Schema) hasEncrypted() bool {
+	for _, field := range info.Fields {
+		if field.isEncrypted() {
+			return true
+		}
+	}
+	return false
+}
+
+// writeValue returns the value of field as it is written to the database, fieldVal is the
+// value of the field in the model. Encrypted fields are sealed, the rest is returned as it is.
+func (s *Storm) writeValue(field *SchemaField, fieldVal reflect.Value) (interface{}, error) {
+	if !field.isEncrypted() {
+		return fieldVal.Interface(), nil
+	}
+	if s.aead == nil {
+		return nil, fmt.Errorf("storm: field %s is encrypted, but no key is configured, see WithEncryption", field.Name)
+	}
+
+	var plain []byte
+	switch {
+	case fieldVal.Kind() == reflect.String:
+		plain = []byte(fieldVal.String())
+	case fieldVal.Kind() == reflect.Slice && fieldVal.Type().Elem().Kind() == reflect.Uint8:
+		if fieldVal.IsNil() {
+			return nil, nil
+		}
+		plain = fieldVal.Bytes()
+	default:
+		return nil, fmt.Errorf("storm: encrypted field %s must be string or []byte, got %s", field.Name, fieldVal.Type())
+	}
+
+	// the nonce is stored in front of the ciphertext, the column name is the additional data,
+	// so a ciphertext copied into another column doesn't decrypt
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("storm: encrypting field %s: %v", field.Name, err)
+	}
+	sealed := s.aead.Seal(nonce, nonce, plain, []byte(field.Column))
+
+	if fieldVal.Kind() == reflect.String {
+		return base64.StdEncoding.EncodeToString(sealed), nil
+	}
+	return sealed, nil
+}
+
+// readValue returns value, as scanned from the column of field, in the form it is set into the field.
+// Encrypted fields are opened, in a masked session masked fields are masked, and times are
+// converted to the location of WithTimeZone.
+func (s *Storm) readValue(field *SchemaField, value interface{}) (interface{}, error) {
+	value, err := s.decrypt(field, value)
+	if err != nil {
+		return nil, err
+	}
+	if s.masked {
+		return maskValue(field, value), nil
+	}
+	return s.inLocation(value), nil
+}
+
+// decrypt opens value, the ciphertext scanned from the column of field, if field is encrypted.
+func (s *Storm) decrypt(field *SchemaField, value interface{}) (interface{}, error) {
+	if !field.isEncrypted() || value == nil {
+		return value, nil
+	}
+	if s.aead == nil {
+		return nil, fmt.Errorf("storm: field %s is encrypted, but no key is configured, see WithEncryption", field.Name)
+	}
+
+	var sealed []byte
+	switch v := value.(type) {
+	case []byte:
+		sealed = v
+	case string:
+		sealed = []byte(v)
+	default:
+		return nil, fmt.Errorf("storm: encrypted field %s: unexpected value of type %T", field.Name, value)
+	}
+	if len(sealed) == 0 {
+		return value, nil
+	}
+
+	if field.Type.Kind() == reflect.String {
+		decoded, err := base64.StdEncoding.DecodeString(string(sealed))
+		if err != nil {
+			return nil, fmt.Errorf("storm: decrypting field %s: %v", field.Name, err)
+		}
+		sealed = decoded
+	}
+
+	size := s.aead.NonceSize()
+	if len(sealed) < size {
+		return nil, fmt.Errorf("storm: decrypting field %s: ciphertext too short", field.Name)
+	}
+	plain, err := s.aead.Open(nil, sealed[:size], sealed[size:], []byte(field.Column))
+	if err != nil {
+		return nil, fmt.Errorf("storm: decrypting field %s: %v", field.Name, err)
+	}
+
+	if field.Type.Kind() == reflect.String {
+		return string(plain), nil
+	}
+	return plain, nil
+}
@@ -0,0 +1,39 @@
+package storm
+
+import "errors"
+
+var (
+	// ErrSchemaMismatch is returned in strict scanning mode when the columns of a result set
+	// and the fields of the destination struct do not match each other.
+	ErrSchemaMismatch = errors.New("storm: result columns do not match struct fields")
+
+	// ErrNoFieldsToUpdate is returned by Update when every non primary key field of the model
+	// is zero, so there is no column left to put in the SET clause.
+	ErrNoFieldsToUpdate = errors.New("storm: no fields to update")
+
+	// ErrRecordNotFound is returned by the typed API (Find, TypedQuery.First) when no row match.
+	ErrRecordNotFound = errors.New("storm: record not found")
+
+	// ErrMissingWhereClause is returned by the update and delete builders when no condition was given,
+	// to protect against changing every row of a table by accident.
+	ErrMissingWhereClause = errors.New("storm: missing WHERE clause")
+
+	// ErrDuplicateKey is returned when a write violates a unique constraint, like inserting an
+	// email that already exists. It wraps the error of the driver, which errors.As still finds.
+	ErrDuplicateKey = errors.New("storm: duplicate key")
+
+	// ErrStaleObject is returned by Update when the model has a version field (`storm:"version"`)
+	// and the row was changed by someone else since the model was read.
+	ErrStaleObject = errors.New("storm: stale object, the row was changed since it was read")
+
+	// ErrReadOnly is returned when a session created with ReadOnly is asked to write.
+	ErrReadOnly = errors.New("storm: read only session can't write")
+
+	// ErrValidation is wrapped by the ValidationErrors returned when fields of a model break
+	// the validation rules of their tags, like `storm:"required;max:255"`.
+	ErrValidation = errors.New("storm: validation failed")
+
+	// ErrLocked is returned when a row can't be locked right away because another transaction
+	// holds its lock, like by LockRecord. It wraps the error of the driver.
+	ErrLocked = errors.New("storm: record is locked by another transaction")
+)
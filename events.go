@@ -0,0 +1,120 @@
+package storm
+
+import (
+	"reflect"
+	"sync"
+)
+
+// ChangeType is what happened to a model in a ChangeEvent.
+type ChangeType int
+
+const (
+	ChangeCreated ChangeType = iota // ChangeCreated, the model was inserted (Insert, Upsert)
+	ChangeUpdated                   // ChangeUpdated, the model was updated (Update)
+	ChangeDeleted                   // ChangeDeleted, the model was deleted (Delete)
+)
+
+func (t ChangeType) String() string {
+	switch t {
+	case ChangeCreated:
+		return "created"
+	case ChangeUpdated:
+		return "updated"
+	case ChangeDeleted:
+		return "deleted"
+	}
+	return "unknown"
+}
+
+// ChangeEvent is emitted after a model was written successfully, Update and Delete only emit it
+// when they changed a row.
+type ChangeEvent struct {
+	Type  ChangeType
+	Table string
+	Model interface{} // Model, the model given to the operation, for example *User
+	// Diff, the columns written with their new value: every column for created,
+	// the non-zero columns Update sets for updated, and nil for deleted. The columns tagged
+	// with encrypt are left out, their plaintext never reaches the listeners.
+	Diff map[string]interface{}
+}
+
+// eventBus, is where the change listeners are registered, shared by every session
+type eventBus struct {
+	mu        sync.RWMutex
+	listeners []func(ChangeEvent)
+}
+
+// OnChange registers fn to be called with every ChangeEvent, for example to invalidate a cache
+// or send a webhook. It is called after the statement succeeded, or inside a transaction after
+// the transaction was committed, in the goroutine that wrote the model.
+func (s *Storm) OnChange(fn func(e ChangeEvent)) {
+	s.events.mu.Lock()
+	defer s.events.mu.Unlock()
+	s.events.listeners = append(s.events.listeners, fn)
+}
+
+// Changes returns a channel receiving every ChangeEvent, like OnChange.
+// Sending blocks the write that emitted the event while the channel is full,
+// so give it a buffer and keep reading from it.
+func (s *Storm) Changes(buffer int) <-chan ChangeEvent {
+	ch := make(chan ChangeEvent, buffer)
+	s.OnChange(func(e ChangeEvent) {
+		ch <- e
+	})
+	return ch
+}
+
+// emitChange emits the change event of the after hook kind on model, if it is a write.
+// Inside a transaction the event is kept until the transaction commits.
+func (s *Storm) emitChange(kind HookKind, model interface{}) {
+	var e ChangeEvent
+	switch kind {
+	case HookAfterInsert:
+		e.Type = ChangeCreated
+	case HookAfterUpdate:
+		e.Type = ChangeUpdated
+	case HookAfterDelete:
+		e.Type = ChangeDeleted
+	default:
+		return
+	}
+
+	s.events.mu.RLock()
+	listening := len(s.events.listeners) > 0
+	s.events.mu.RUnlock()
+	if !listening {
+		return
+	}
+
+	val := reflect.ValueOf(model).Elem()
+	info := s.schema.parseType(val.Type())
+	e.Table = s.tableName(info.Table)
+	e.Model = model
+	if e.Type != ChangeDeleted {
+		e.Diff = map[string]interface{}{}
+		for _, field := range info.Fields {
+			fieldVal := field.Value(val)
+			if field.isEncrypted() || (e.Type == ChangeUpdated && (field.PK || fieldVal.IsZero())) {
+				continue
+			}
+			e.Diff[field.Column] = fieldVal.Interface()
+		}
+	}
+
+	if s.pendingChanges != nil {
+		*s.pendingChanges = append(*s.pendingChanges, e)
+		return
+	}
+	s.events.publish(e)
+}
+
+// publish calls every listener with e.
+func (b *eventBus) publish(e ChangeEvent) {
+	b.mu.RLock()
+	listeners := b.listeners
+	b.mu.RUnlock()
+
+	for _, fn := range listeners {
+		fn(e)
+	}
+}
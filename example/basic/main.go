@@ -31,19 +31,21 @@ func main() {
 	// 	Name:  "ammar",
 	// 	Email: "dikha@pepeg.com",
 	// }
-	// err = storm.Update(user)
+	// affected, err := storm.Update(user)
 	// if err != nil {
 	// 	log.Fatal("Error update data:", err.Error())
 	// }
+	// fmt.Println("Updated rows:", affected)
 
 	// delete
 	// user := &models.User{
 	// 	ID: 5,
 	// }
-	// err = storm.Delete(user)
+	// affected, err := storm.Delete(user)
 	// if err != nil {
 	// 	log.Fatal("Error delete data:", err.Error())
 	// }
+	// fmt.Println("Deleted rows:", affected)
 
 	// query
 	// SELECT
@@ -61,19 +63,15 @@ func main() {
 	// 	First(&user)
 
 	// Pagination
-	var user []models.User
-	var total, totalPages int
-	page := 2
-	pageSize := 3
-	err = storm.
+	page, err := storm.
 		From(&models.User{}).
-		Paginate(&user, page, pageSize, &total, &totalPages, "name_user")
+		PaginatePage(2, 3, "name_user")
 	if err != nil {
 		log.Fatal("Error get users: ", err.Error())
 	}
-	fmt.Println("Page: ", page)
-	fmt.Println("Page Size: ", pageSize)
-	fmt.Println("Total User: ", total)
-	fmt.Println("Total Pages: ", totalPages)
-	fmt.Println("User data: ", user)
+	fmt.Println("Page: ", page.Page)
+	fmt.Println("Page Size: ", page.PageSize)
+	fmt.Println("Total User: ", page.Total)
+	fmt.Println("Total Pages: ", page.TotalPages)
+	fmt.Println("User data: ", page.Items.([]models.User))
 }
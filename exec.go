@@ -0,0 +1,105 @@
+package storm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"time"
+)
+
+// Exec executes a hand written statement, like a DDL or a bulk UPDATE storm can't build, the
+// same way as the statements storm builds: it is logged and counted, rebound to the dialect,
+// refused by a ReadOnly session, recorded by DryRun, run in the transaction of the session,
+// and its driver errors are translated (see ErrDuplicateKey). DB().Exec skips all of that:
+//
+//	res, err := db.Exec(ctx, "UPDATE users SET status = $1 WHERE last_login < $2", "dormant", cutoff)
+//
+// The placeholders are $1, $2, ... or ?, like the ones of Where. A nil ctx keeps the context of s.
+func (s *Storm) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if ctx != nil {
+		s = s.WithContext(ctx)
+	}
+	if !hasNumberedPlaceholder(query) {
+		var n int
+		query, n = numberQuestionMarks(query)
+		if n != len(args) {
+			return nil, fmt.Errorf("storm: exec has %d placeholders for %d arguments", n, len(args))
+		}
+	}
+	return s.exec(query, args...)
+}
+
+// exec executes a statement built by storm, converting its placeholders to the dialect first.
+// Every write of storm goes through here, so this is where logging, dry run and the
+// translation of driver errors (see ErrDuplicateKey) happen.
+func (s *Storm) exec(query string, args ...interface{}) (sql.Result, error) {
+	if s.refuses(query) {
+		return nil, ErrReadOnly
+	}
+	query, args = rebind(s.dialect, query, args)
+	return s.execBound(query, bindArgs(s.dialect, s.normalizeTimes(args)))
+}
+
+// execBound executes query like exec, its placeholders and args are already the ones of the dialect.
+func (s *Storm) execBound(query string, args []interface{}) (sql.Result, error) {
+	if s.dryRun {
+		s.dryRunStatement(query, args)
+		return driver.RowsAffected(0), nil
+	}
+
+	start := time.Now()
+	res, err := s.conn.ExecContext(s.ctx, query, args...)
+	s.log(QueryEvent{SQL: query, Args: args, Duration: time.Since(start), Err: err})
+	return res, translateError(err)
+}
+
+// queryRows executes a SELECT built by storm, converting its placeholders to the dialect first,
+// and passes the rows to fn. The rows are closed after fn returns.
+// Every read of storm goes through here, in dry run mode fn is never called, like the query returned no rows.
+func (s *Storm) queryRows(query string, args []interface{}, fn func(rows *sql.Rows) error) error {
+	// writes with RETURNING are queries too
+	if s.refuses(query) {
+		return ErrReadOnly
+	}
+	query, args = rebind(s.dialect, query, args)
+	args = bindArgs(s.dialect, s.normalizeTimes(args))
+
+	if s.dryRun {
+		s.dryRunStatement(query, args)
+		return nil
+	}
+
+	start := time.Now()
+	rows, err := s.queryRetry(query, args)
+	if err != nil {
+		s.log(QueryEvent{SQL: query, Args: args, Duration: time.Since(start), Err: err})
+		return translateError(err)
+	}
+	defer rows.Close()
+
+	err = fn(rows)
+	if err == nil {
+		err = rows.Err()
+	}
+	s.log(QueryEvent{SQL: query, Args: args, Duration: time.Since(start), Err: err})
+	return translateError(err)
+}
+
+// dryRunStatement logs and records a statement that was built but not executed.
+func (s *Storm) dryRunStatement(query string, args []interface{}) {
+	s.log(QueryEvent{SQL: query, Args: args, DryRun: true})
+	if s.recorded != nil {
+		*s.recorded = append(*s.recorded, Statement{SQL: query, Args: args})
+	}
+}
+
+// log sends e to the logger of this handle, if there is one, and counts it for Health.
+func (s *Storm) log(e QueryEvent) {
+	e.Tags = s.tags
+	s.stats.record(e)
+	s.plans.record(e)
+	if s.logger != nil {
+		s.logger.LogQuery(s.ctx, e)
+	}
+}
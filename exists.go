@@ -0,0 +1,48 @@
+package storm
+
+import "fmt"
+
+// existsExpr, is the EXISTS (or NOT EXISTS) condition of a subquery
+type existsExpr struct {
+	sub *Query
+	not bool
+}
+
+// ToSQL builds `EXISTS (SELECT 1 FROM ...)`, the placeholders of the subquery are numbered
+// from $1 like any expression, so they merge with the conditions around it.
+func (e existsExpr) ToSQL() (string, []interface{}, error) {
+	if e.sub.err != nil {
+		return "", nil, e.sub.err
+	}
+	if e.sub.raw != "" {
+		return "", nil, fmt.Errorf("storm: named queries can't be used as subquery")
+	}
+	sql, args, err := e.sub.buildSelect([]string{"1"}, e.sub.limit)
+	if err != nil {
+		return "", nil, err
+	}
+	if e.not {
+		return "NOT EXISTS (" + sql + ")", args, nil
+	}
+	return "EXISTS (" + sql + ")", args, nil
+}
+
+// WhereExists adds the condition that sub returns at least one row. sub is usually correlated
+// with the outer query through its table name:
+//
+//	orders := db.Table("orders").Where("orders.user_id = users.id").Where("orders.total > $1", 100)
+//	db.From(&User{}).Where("status = $1", "active").WhereExists(orders).Select(&users)
+//	// SELECT * FROM users WHERE (status = $1)
+//	//   AND (EXISTS (SELECT 1 FROM orders WHERE (orders.user_id = users.id) AND (orders.total > $2)))
+//
+// The global scopes of the subquery table apply to it too.
+func (q *Query) WhereExists(sub *Query) *Query {
+	q.where = append(q.where, existsExpr{sub: sub})
+	return q
+}
+
+// WhereNotExists adds the condition that sub returns no row, see WhereExists.
+func (q *Query) WhereNotExists(sub *Query) *Query {
+	q.where = append(q.where, existsExpr{sub: sub, not: true})
+	return q
+}
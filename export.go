@@ -0,0 +1,233 @@
+package storm
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// CSVOptions configures ExportCSV, the zero value writes every column with a header row.
+type CSVOptions struct {
+	Columns    []string // Columns, to export, empty means the SelectFields/Omit of the query or every column
+	NoHeader   bool     // NoHeader, don't write the header row
+	Header     []string // Header, names of the header row instead of the column names, one per column
+	Comma      rune     // Comma, field delimiter, default ','
+	TimeFormat string   // TimeFormat, layout of time values, default time.RFC3339
+}
+
+// ExportCSV runs the query and writes its rows to w as CSV, one row at a time, so big reports
+// can be streamed to a download without loading them in memory. Values are quoted when needed
+// (delimiters, quotes, new lines), NULL is an empty field.
+//
+//	w.Header().Set("Content-Type", "text/csv")
+//	err := db.From(&Order{}).Where("created_at >= $1", from).ExportCSV(w, storm.CSVOptions{
+//		Columns: []string{"id", "total", "created_at"},
+//		Header:  []string{"Order", "Total", "Date"},
+//	})
+//
+// Queries started from a model decrypt and mask its fields like Select does.
+func (q *Query) ExportCSV(w io.Writer, opts CSVOptions) error {
+	if opts.TimeFormat == "" {
+		opts.TimeFormat = time.RFC3339
+	}
+
+	out := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		out.Comma = opts.Comma
+	}
+
+	record := []string{}
+	err := q.export(opts.Columns, func(cols []string, vals []interface{}) error {
+		if vals == nil {
+			if opts.NoHeader {
+				return nil
+			}
+			header := cols
+			if len(opts.Header) > 0 {
+				if len(opts.Header) != len(cols) {
+					return fmt.Errorf("storm: csv header has %d names for %d columns", len(opts.Header), len(cols))
+				}
+				header = opts.Header
+			}
+			return out.Write(header)
+		}
+
+		record = record[:0]
+		for _, v := range vals {
+			record = append(record, formatCSV(v, opts.TimeFormat))
+		}
+		return out.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// export runs the query selecting queryCol and calls fn with the column names and a nil vals
+// once before the first row, then with the values of every row, decrypted and masked when the
+// query has a model. The values of json columns are given as json.RawMessage.
+func (q *Query) export(queryCol []string, fn func(cols []string, vals []interface{}) error) error {
+	if q.err != nil {
+		return q.err
+	}
+
+	var modelType reflect.Type
+	if q.model != nil {
+		modelType = reflect.TypeOf(q.model).Elem()
+		var err error
+		if queryCol, err = q.selectColumns(queryCol, modelType); err != nil {
+			return err
+		}
+	}
+
+	query, args, err := q.buildSelect(queryCol, q.limit)
+	if err != nil {
+		return err
+	}
+
+	return q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		types, err := rows.ColumnTypes()
+		if err != nil {
+			return err
+		}
+		cols := make([]string, len(types))
+		for i, t := range types {
+			cols[i] = t.Name()
+		}
+		var fields map[string]*SchemaField
+		if modelType != nil {
+			fields = q.fieldsFor(modelType, cols)
+		}
+
+		isJSON := make([]bool, len(types))
+		for i, t := range types {
+			switch t.DatabaseTypeName() {
+			case "JSON", "JSONB":
+				isJSON[i] = true
+			}
+			if field, ok := fields[cols[i]]; ok && (field.Type == rawMessageType || field.Type == reflect.PointerTo(rawMessageType)) {
+				isJSON[i] = true
+			}
+		}
+		if err := fn(cols, nil); err != nil {
+			return err
+		}
+
+		for rows.Next() {
+			vals, err := scanValues(rows, len(cols))
+			if err != nil {
+				return err
+			}
+			for i, col := range cols {
+				if field, ok := fields[col]; ok {
+					if vals[i], err = q.storm.readValue(field, vals[i]); err != nil {
+						return err
+					}
+				}
+				if isJSON[i] {
+					vals[i] = toRawMessage(vals[i])
+				}
+			}
+			if err := fn(cols, vals); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// formatCSV formats v, a value scanned from the database, as a CSV field.
+func formatCSV(v interface{}, timeFormat string) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case json.RawMessage:
+		return string(v)
+	case string:
+		return v
+	case time.Time:
+		return v.Format(timeFormat)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// ExportNDJSON runs the query and writes its rows to w as newline delimited JSON, one object
+// per row with the columns as keys in the order of the statement, for data pipelines:
+//
+//	{"id":1,"name_user":"aji","metadata":{"plan":"pro"}}
+//
+// json and jsonb columns (and json.RawMessage fields of the model) are embedded as JSON,
+// binary values that are not text are base64 like encoding/json does.
+func (q *Query) ExportNDJSON(w io.Writer, queryCol ...string) error {
+	var keys [][]byte
+	var line bytes.Buffer
+	return q.export(queryCol, func(cols []string, vals []interface{}) error {
+		if vals == nil {
+			// the keys are the same for every row, so we encode them once
+			for _, col := range cols {
+				key, err := json.Marshal(col)
+				if err != nil {
+					return err
+				}
+				keys = append(keys, key)
+			}
+			return nil
+		}
+
+		line.Reset()
+		line.WriteByte('{')
+		for i, v := range vals {
+			if i > 0 {
+				line.WriteByte(',')
+			}
+			line.Write(keys[i])
+			line.WriteByte(':')
+
+			if b, ok := v.([]byte); ok && utf8.Valid(b) {
+				v = string(b)
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("storm: column %s: %v", cols[i], err)
+			}
+			line.Write(value)
+		}
+		line.WriteString("}\n")
+
+		_, err := w.Write(line.Bytes())
+		return err
+	})
+}
+
+// toRawMessage returns v, the value of a json column, as json.RawMessage,
+// values that are not valid json (like a masked value) are returned as they are.
+func toRawMessage(v interface{}) interface{} {
+	switch text := v.(type) {
+	case []byte:
+		if json.Valid(text) {
+			return json.RawMessage(text)
+		}
+	case string:
+		if json.Valid([]byte(text)) {
+			return json.RawMessage(text)
+		}
+	}
+	return v
+}
@@ -0,0 +1,300 @@
+package storm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Expr is a SQL condition together with its arguments.
+// Placeholders in the SQL are numbered from $1, storm renumbers them when expressions
+// are combined with each other or with the rest of the statement.
+// Example: q.WhereExpr(storm.And(storm.Eq{"status": "active"}, storm.Gt{"age": 18}))
+type Expr interface {
+	ToSQL() (string, []interface{}, error)
+}
+
+// rawExpr, is hand written condition like "id = $1", what Where(string, args...) stores
+type rawExpr struct {
+	sql  string
+	args []interface{}
+}
+
+func (r rawExpr) ToSQL() (string, []interface{}, error) {
+	return r.sql, r.args, nil
+}
+
+// Eq is equality condition, every key is a column: Eq{"status": "active"} is `status = $1`.
+// A nil value becomes `IS NULL` and a slice value becomes `IN (...)`.
+type Eq map[string]interface{}
+
+// NotEq is inequality condition: NotEq{"status": "banned"} is `status <> $1`.
+// A nil value becomes `IS NOT NULL` and a slice value becomes `NOT IN (...)`.
+type NotEq map[string]interface{}
+
+// Gt is greater than condition: Gt{"age": 18} is `age > $1`.
+type Gt map[string]interface{}
+
+// GtOrEq is greater than or equal condition: GtOrEq{"age": 18} is `age >= $1`.
+type GtOrEq map[string]interface{}
+
+// Lt is less than condition: Lt{"age": 18} is `age < $1`.
+type Lt map[string]interface{}
+
+// LtOrEq is less than or equal condition: LtOrEq{"age": 18} is `age <= $1`.
+type LtOrEq map[string]interface{}
+
+// In is IN condition, the values must be slices: In{"id": []int{1, 2, 3}} is `id IN ($1,$2,$3)`.
+type In map[string]interface{}
+
+func (e Eq) ToSQL() (string, []interface{}, error) {
+	return compare(e, "=", "IS NULL", "IN")
+}
+
+func (e NotEq) ToSQL() (string, []interface{}, error) {
+	return compare(e, "<>", "IS NOT NULL", "NOT IN")
+}
+
+func (e Gt) ToSQL() (string, []interface{}, error) {
+	return compare(e, ">", "", "")
+}
+
+func (e GtOrEq) ToSQL() (string, []interface{}, error) {
+	return compare(e, ">=", "", "")
+}
+
+func (e Lt) ToSQL() (string, []interface{}, error) {
+	return compare(e, "<", "", "")
+}
+
+func (e LtOrEq) ToSQL() (string, []interface{}, error) {
+	return compare(e, "<=", "", "")
+}
+
+func (e In) ToSQL() (string, []interface{}, error) {
+	for col, v := range e {
+		if !isList(v) {
+			return "", nil, fmt.Errorf("storm: In value of %s must be a slice, got %T", col, v)
+		}
+	}
+	return compare(e, "", "", "IN")
+}
+
+// compare builds `col op $n` for every key of m (sorted, so the SQL is stable) and AND them.
+// nullOp is used for nil value and listOp for slice value, empty means not supported.
+func compare(m map[string]interface{}, op, nullOp, listOp string) (string, []interface{}, error) {
+	columns := make([]string, 0, len(m))
+	for col := range m {
+		columns = append(columns, col)
+	}
+	sort.Strings(columns)
+
+	var parts []string
+	var args []interface{}
+	for _, col := range columns {
+		v := m[col]
+		switch {
+		case v == nil:
+			if nullOp == "" {
+				return "", nil, fmt.Errorf("storm: cannot compare %s with NULL using %s", col, op)
+			}
+			parts = append(parts, fmt.Sprintf("%s %s", col, nullOp))
+
+		case isList(v):
+			if listOp == "" {
+				return "", nil, fmt.Errorf("storm: cannot compare %s with a list using %s", col, op)
+			}
+			list := reflect.ValueOf(v)
+			if list.Len() == 0 {
+				// IN () is not valid SQL, an empty IN never match and an empty NOT IN always match
+				if listOp == "IN" {
+					parts = append(parts, "(1=0)")
+				} else {
+					parts = append(parts, "(1=1)")
+				}
+				continue
+			}
+			placeholders := make([]string, list.Len())
+			for i := 0; i < list.Len(); i++ {
+				args = append(args, list.Index(i).Interface())
+				placeholders[i] = fmt.Sprintf("$%d", len(args))
+			}
+			parts = append(parts, fmt.Sprintf("%s %s (%s)", col, listOp, strings.Join(placeholders, ",")))
+
+		default:
+			args = append(args, v)
+			parts = append(parts, fmt.Sprintf("%s %s $%d", col, op, len(args)))
+		}
+	}
+	return strings.Join(parts, " AND "), args, nil
+}
+
+// isList reports whether v is a slice or array we should expand. A slice of bytes ([]byte,
+// json.RawMessage, a [16]byte uuid) and a driver.Valuer (pq.StringArray, pq.Int64Array) are a
+// single value not a list, and nil is not a list either.
+func isList(v interface{}) bool {
+	if _, ok := v.(driver.Valuer); ok || v == nil {
+		return false
+	}
+	t := reflect.TypeOf(v)
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	return t.Elem().Kind() != reflect.Uint8
+}
+
+// and / or, are the expression that combine other expressions
+type and []Expr
+type or []Expr
+
+// And combines exprs so all of them must match: (a AND b AND ...).
+func And(exprs ...Expr) Expr { return and(exprs) }
+
+// Or combines exprs so at least one of them must match: (a OR b OR ...).
+func Or(exprs ...Expr) Expr { return or(exprs) }
+
+func (a and) ToSQL() (string, []interface{}, error) {
+	return joinExprs(a, " AND ")
+}
+
+func (o or) ToSQL() (string, []interface{}, error) {
+	return joinExprs(o, " OR ")
+}
+
+// joinExprs joins exprs with sep, renumbering the placeholders of every expression
+// so they continue after the arguments of the previous ones.
+// When there is more than one expression each of them is wrapped in parentheses, so an OR
+// inside never leaks out of its expression.
+func joinExprs(exprs []Expr, sep string) (string, []interface{}, error) {
+	var parts []string
+	var args []interface{}
+	for _, e := range exprs {
+		sql, exprArgs, err := e.ToSQL()
+		if err != nil {
+			return "", nil, err
+		}
+		if sql == "" {
+			continue
+		}
+		parts = append(parts, shiftPlaceholders(sql, len(args)))
+		args = append(args, exprArgs...)
+	}
+
+	if len(parts) == 0 {
+		return "", nil, nil
+	}
+	if len(parts) == 1 {
+		return parts[0], args, nil
+	}
+	for i, part := range parts {
+		parts[i] = "(" + part + ")"
+	}
+	return strings.Join(parts, sep), args, nil
+}
+
+// toExpr turns the condition given to a Where method into an expression.
+// condition can be a SQL string with its args, a map[string]interface{} (ANDed equality, like Eq),
+// an Expr, or a (pointer to) model struct whose non-zero fields become ANDed equality conditions.
+// The SQL string can use `?` placeholders instead of $1, $2, ..., so the code doesn't depend on
+// the dialect, they are numbered here and rebound like the others when the statement runs.
+func (s *Storm) toExpr(condition interface{}, args ...interface{}) (Expr, error) {
+	switch c := condition.(type) {
+	case string:
+		if hasNumberedPlaceholder(c) {
+			return rawExpr{c, args}, nil
+		}
+		// without args too, so ?? is the ? operator whether the condition has args or not
+		sql, n := numberQuestionMarks(c)
+		if n != len(args) {
+			return nil, fmt.Errorf("storm: where %q has %d ? placeholders for %d arguments (write ?? for the ? operator)", c, n, len(args))
+		}
+		return rawExpr{sql, args}, nil
+	case map[string]interface{}:
+		return Eq(c), nil
+	case Expr:
+		return c, nil
+	}
+
+	val := reflect.ValueOf(condition)
+	if val.Kind() == reflect.Ptr && !val.IsNil() {
+		val = val.Elem()
+	}
+	if val.Kind() == reflect.Struct {
+		return s.structExpr(val), nil
+	}
+	return nil, fmt.Errorf("storm: unsupported where condition of type %T", condition)
+}
+
+// hasNumberedPlaceholder reports whether sql has a $1 style placeholder outside quoted literals.
+func hasNumberedPlaceholder(sql string) bool {
+	inQuote := false
+	for i := 0; i < len(sql); i++ {
+		if sql[i] == '\'' {
+			inQuote = !inQuote
+		}
+		if sql[i] == '$' && !inQuote && i+1 < len(sql) && isDigit(sql[i+1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// numberQuestionMarks replaces the `?` placeholders of sql, outside quoted literals, with $1, $2, ...
+// and returns how many there were. The postgres operators ?| and ?& are left alone, and ?? is
+// the ? operator (`data ?? 'key'`), the same escape as the JDBC drivers.
+func numberQuestionMarks(sql string) (string, int) {
+	var b strings.Builder
+	n := 0
+	inQuote := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == '\'' {
+			inQuote = !inQuote
+		}
+		if c != '?' || inQuote {
+			b.WriteByte(c)
+			continue
+		}
+		if i+1 < len(sql) && (sql[i+1] == '?' || sql[i+1] == '|' || sql[i+1] == '&') {
+			b.WriteByte('?')
+			if sql[i+1] != '?' {
+				b.WriteByte(sql[i+1])
+			}
+			i++
+			continue
+		}
+		n++
+		b.WriteString("$" + strconv.Itoa(n))
+	}
+	return b.String(), n
+}
+
+// structExpr builds an Eq from the non-zero fields of a model struct,
+// so db.From(&User{}).Where(&User{Email: "a@b.com"}) becomes `email_user = $1`.
+func (s *Storm) structExpr(val reflect.Value) Expr {
+	eq := Eq{}
+	for _, field := range s.schema.parseType(val.Type()).Fields {
+		fieldVal := field.Value(val)
+		if fieldVal.IsZero() {
+			continue
+		}
+		eq[field.Column] = fieldVal.Interface()
+	}
+	return eq
+}
+
+// whereClause collects the conditions of a statement, they are joined with AND.
+type whereClause []Expr
+
+// build returns the conditions as one SQL (without the WHERE keyword), with the placeholders
+// numbered after offset arguments that come before the WHERE clause in the statement.
+func (w whereClause) build(offset int) (string, []interface{}, error) {
+	sql, args, err := joinExprs(w, " AND ")
+	if err != nil {
+		return "", nil, err
+	}
+	return shiftPlaceholders(sql, offset), args, nil
+}
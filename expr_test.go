@@ -0,0 +1,112 @@
+package storm_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/pepega90/storm"
+)
+
+func TestEqValues(t *testing.T) {
+	uuid := [16]byte{1, 2, 3}
+	tests := []struct {
+		name string
+		expr storm.Expr
+		sql  string
+		args []interface{}
+	}{
+		{"list", storm.Eq{"id": []int{1, 2}}, "id IN ($1,$2)", []interface{}{1, 2}},
+		{"nil", storm.Eq{"id": nil}, "id IS NULL", nil},
+		// a slice of bytes or a driver.Valuer is a single value, never expanded
+		{"bytes", storm.Eq{"data": []byte("ab")}, "data = $1", []interface{}{[]byte("ab")}},
+		{"raw json", storm.Eq{"data": json.RawMessage(`{}`)}, "data = $1", []interface{}{json.RawMessage(`{}`)}},
+		{"byte array", storm.Eq{"uuid": uuid}, "uuid = $1", []interface{}{uuid}},
+		{"valuer", storm.Eq{"tags": pq.StringArray{"a", "b"}}, "tags = $1", []interface{}{pq.StringArray{"a", "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.expr.ToSQL()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.sql || !sameArgs(args, tt.args) {
+				t.Errorf("got %s %v, want %s %v", sql, args, tt.sql, tt.args)
+			}
+		})
+	}
+
+	if _, _, err := (storm.In{"tags": pq.Int64Array{1}}).ToSQL(); err == nil {
+		t.Error("In of a driver.Valuer: expected an error")
+	}
+}
+
+func TestWhereAnyValuer(t *testing.T) {
+	db := newDryRun(t)
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.From(&user{}).WhereAny("name", pq.StringArray{"a", "b"}).Select(&[]user{})
+	}, storm.Statement{
+		SQL:  "SELECT * FROM users WHERE name = ANY($1)",
+		Args: []interface{}{pq.StringArray{"a", "b"}},
+	})
+}
+
+func TestWhere(t *testing.T) {
+	db := newDryRun(t)
+	tests := []struct {
+		name  string
+		query func(q *storm.Query) *storm.Query
+		want  storm.Statement
+	}{
+		{
+			"question marks",
+			func(q *storm.Query) *storm.Query { return q.Where("name = ? AND id > ?", "a", 1) },
+			storm.Statement{SQL: "SELECT * FROM users WHERE name = $1 AND id > $2", Args: []interface{}{"a", 1}},
+		},
+		{
+			"question mark operator",
+			func(q *storm.Query) *storm.Query { return q.Where("data ?? 'key' AND data ?| ?", "{a}") },
+			storm.Statement{SQL: "SELECT * FROM users WHERE data ? 'key' AND data ?| $1", Args: []interface{}{"{a}"}},
+		},
+		{
+			"conditions numbered one after the other",
+			func(q *storm.Query) *storm.Query {
+				return q.Where("name = $1", "a").Where(map[string]interface{}{"id": []int{1, 2}}).Where(&user{Name: "b"})
+			},
+			storm.Statement{SQL: "SELECT * FROM users WHERE (name = $1) AND (id IN ($2,$3)) AND (name = $4)", Args: []interface{}{"a", 1, 2, "b"}},
+		},
+		{
+			"and or",
+			func(q *storm.Query) *storm.Query {
+				return q.WhereExpr(storm.Or(
+					storm.And(storm.Eq{"name": "a"}, storm.Gt{"id": 1}),
+					storm.NotEq{"name": nil},
+					storm.In{"id": []int{}},
+				))
+			},
+			storm.Statement{SQL: "SELECT * FROM users WHERE ((name = $1) AND (id > $2)) OR (name IS NOT NULL) OR ((1=0))", Args: []interface{}{"a", 1}},
+		},
+		{
+			"or where",
+			func(q *storm.Query) *storm.Query { return q.Where("name = ?", "a").OrWhere("id = ?", 2) },
+			storm.Statement{SQL: "SELECT * FROM users WHERE (name = $1) OR (id = $2)", Args: []interface{}{"a", 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSQL(t, db, func(tx *storm.Storm) error {
+				return tt.query(tx.From(&user{})).Select(&[]user{})
+			}, tt.want)
+		})
+	}
+}
+
+func TestWhereError(t *testing.T) {
+	db := newDryRun(t)
+	_, err := db.ToSQL(func(tx *storm.Storm) error {
+		return tx.From(&user{}).Where("name = ? AND id = ?", "a").Select(&[]user{})
+	})
+	if err == nil {
+		t.Error("expected an error for 2 ? placeholders with 1 argument")
+	}
+}
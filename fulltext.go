@@ -0,0 +1,32 @@
+package storm
+
+import "fmt"
+
+// WhereFullText adds a postgres full-text search condition on column, a tsvector column
+// (or expression) matched against search with plainto_tsquery, so every word must match.
+// Example: .WhereFullText("search_vector", "golang orm")
+// is `search_vector @@ plainto_tsquery($1)`.
+func (q *Query) WhereFullText(column, search string) *Query {
+	q.where = append(q.where, rawExpr{fmt.Sprintf("%s @@ plainto_tsquery($1)", column), []interface{}{search}})
+	return q
+}
+
+// RankFullText selects the ts_rank of column for search as the column named as, and orders
+// the results by it, the best match first. Map it to a float field of the destination, named
+// like as or with a column tag (see SelectAs), the other columns are selected as usual:
+//
+//	type Article struct {
+//		ID    int     `storm:"pk"`
+//		Title string
+//		Rank  float64 `storm:"column:rank"`
+//	}
+//
+//	db.From(&Article{}).
+//		WhereFullText("search_vector", "golang orm").
+//		RankFullText("search_vector", "golang orm", "rank").
+//		Select(&articles)
+func (q *Query) RankFullText(column, search, as string) *Query {
+	q.SelectAs(fmt.Sprintf("ts_rank(%s, plainto_tsquery($1))", column), as, search)
+	q.orders = append(q.orders, orderTerm{expr: rawExpr{as, nil}, dir: "DESC"})
+	return q
+}
@@ -0,0 +1,76 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// generated reports whether field is tagged with generated, a column the database computes
+// (GENERATED ALWAYS, identity, defaults maintained by triggers). It is never written and is read
+// back with RETURNING after Insert, Update and Upsert.
+func (f *SchemaField) generated() bool {
+	_, ok := f.Tag["generated"]
+	return ok
+}
+
+// writable reports whether storm writes field in INSERT and UPDATE statements.
+func (f *SchemaField) writable() bool {
+	return !f.readOnly() && !f.generated()
+}
+
+// generatedFields returns the fields of info tagged with generated.
+func (info *Schema) generatedFields() []*SchemaField {
+	var fields []*SchemaField
+	for _, field := range info.Fields {
+		if field.generated() {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// execReturning executes q, a write statement, with a RETURNING clause of fields and sets the
+// values of the returned rows, in order, into vals, the model structs. It returns the number of
+// rows returned, which is the number of rows written.
+// mysql has no RETURNING, there q is only executed and the fields keep their value.
+func (s *Storm) execReturning(q string, args []interface{}, vals []reflect.Value, fields []*SchemaField) (int64, error) {
+	if s.dialect.Name() == "mysql" {
+		res, err := s.exec(q, args...)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Column
+	}
+	q += " RETURNING " + strings.Join(columns, ", ")
+
+	var n int64
+	err := s.queryRows(q, args, func(rows *sql.Rows) error {
+		for ; rows.Next(); n++ {
+			if n >= int64(len(vals)) {
+				continue
+			}
+			returned, err := scanValues(rows, len(fields))
+			if err != nil {
+				return err
+			}
+			for i, field := range fields {
+				value, err := s.readValue(field, returned[i])
+				if err != nil {
+					return err
+				}
+				if err := setFieldValue(field.Alloc(vals[n]), value); err != nil {
+					return fmt.Errorf("error setting field %s: %v", field.Name, err)
+				}
+			}
+		}
+		return nil
+	})
+	return n, err
+}
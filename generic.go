@@ -0,0 +1,127 @@
+package storm
+
+// Find loads the record of model T whose primary key equals id.
+// It returns ErrRecordNotFound if there is no such row.
+// Example usage: user, err := storm.Find[User](db, 42)
+func Find[T any](s *Storm, id interface{}) (T, error) {
+	var dest T
+	err := s.Get(&dest, id)
+	return dest, err
+}
+
+// TypedQuery is the generic version of Query, it knows its model type T
+// so the results come back as T instead of being scanned into an interface{} destination.
+type TypedQuery[T any] struct {
+	q *Query
+}
+
+// PageOf is one page of T returned by Paginate and TypedQuery.Page. It can be encoded to JSON
+// directly, with the keys of Page, and Items is never null.
+type PageOf[T any] struct {
+	Items      []T  `json:"items"`
+	Total      int  `json:"total"`
+	TotalPages int  `json:"total_pages"`
+	Page       int  `json:"page"`
+	PageSize   int  `json:"page_size"`
+	HasNext    bool `json:"has_next"`
+}
+
+// Paginate executes q with pagination and returns the page of T together with its totals,
+// q is usually started from the model T:
+//
+//	page, err := storm.Paginate[User](db.From(&User{}).Where("active = $1", true), 2, 20)
+//	json.NewEncoder(w).Encode(page) // {"items":[...],"total":41,"total_pages":3,...}
+func Paginate[T any](q *Query, page, pageSize int, queryCol ...string) (PageOf[T], error) {
+	items := []T{}
+	p, err := q.paginate(&items, page, pageSize, queryCol...)
+	if err != nil {
+		return PageOf[T]{}, err
+	}
+
+	return PageOf[T]{
+		Items:      items,
+		Total:      p.Total,
+		TotalPages: p.TotalPages,
+		Page:       p.Page,
+		PageSize:   p.PageSize,
+		HasNext:    p.HasNext,
+	}, nil
+}
+
+// QueryOf starts a typed query for model T.
+// Example usage: users, err := storm.QueryOf[User](db).Where("id > $1", 10).All()
+func QueryOf[T any](s *Storm) *TypedQuery[T] {
+	return &TypedQuery[T]{q: s.From(new(T))}
+}
+
+// Where adds a WHERE condition with optional arguments to the query, see Query.Where.
+func (t *TypedQuery[T]) Where(condition interface{}, args ...interface{}) *TypedQuery[T] {
+	t.q.Where(condition, args...)
+	return t
+}
+
+// WhereExpr adds a condition built with the expression DSL to the query.
+func (t *TypedQuery[T]) WhereExpr(expr Expr) *TypedQuery[T] {
+	t.q.WhereExpr(expr)
+	return t
+}
+
+// SelectFields chooses the projected columns by Go field names, see Query.SelectFields.
+func (t *TypedQuery[T]) SelectFields(fields ...string) *TypedQuery[T] {
+	t.q.SelectFields(fields...)
+	return t
+}
+
+// Omit leaves the given Go fields out of the projected columns, see Query.Omit.
+func (t *TypedQuery[T]) Omit(fields ...string) *TypedQuery[T] {
+	t.q.Omit(fields...)
+	return t
+}
+
+// Limit adds a LIMIT clause to the query.
+func (t *TypedQuery[T]) Limit(n int) *TypedQuery[T] {
+	t.q.Limit(n)
+	return t
+}
+
+// Strict turns on strict scanning mode, see Query.Strict.
+func (t *TypedQuery[T]) Strict() *TypedQuery[T] {
+	t.q.Strict()
+	return t
+}
+
+// Tag attaches key=value to the statements of the query, see Query.Tag.
+func (t *TypedQuery[T]) Tag(key, value string) *TypedQuery[T] {
+	t.q.Tag(key, value)
+	return t
+}
+
+// All executes the query and returns every matching row.
+func (t *TypedQuery[T]) All(queryCol ...string) ([]T, error) {
+	var items []T
+	err := t.q.Select(&items, queryCol...)
+	return items, err
+}
+
+// First executes the query and returns the first matching row,
+// or ErrRecordNotFound if there is none.
+func (t *TypedQuery[T]) First(queryCol ...string) (T, error) {
+	var dest T
+	found, err := t.q.first(&dest, queryCol...)
+	if err != nil {
+		return dest, err
+	}
+	if !found {
+		return dest, ErrRecordNotFound
+	}
+	return dest, nil
+}
+
+// Page executes the query with pagination and returns the page together with its totals, see Paginate.
+func (t *TypedQuery[T]) Page(page, pageSize int, queryCol ...string) (*PageOf[T], error) {
+	result, err := Paginate[T](t.q, page, pageSize, queryCol...)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,131 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// GetOrInsert inserts model, or loads the existing row into model when it conflicts with the
+// unique index of conflict (the primary key when conflict is empty), and reports whether the
+// row was inserted. Concurrent callers never race: the insert is
+// `INSERT ... ON CONFLICT (email_user) DO NOTHING RETURNING *`, and only when it inserted
+// nothing the existing row is selected by the conflict columns:
+//
+//	user := User{Email: "aji@handsome.com", Name: "aji"}
+//	created, err := db.GetOrInsert(&user, []string{"email_user"})
+//	// user.ID is the id of the new row, or of the one that already existed
+//
+// BeforeInsert is always called, since the row may be inserted, and it can fill the conflict
+// columns. AfterInsert is only called for an inserted row, AfterFind for a loaded one.
+// On mysql ON DUPLICATE KEY UPDATE ignores the conflict, and the row is always selected afterwards.
+func (s *Storm) GetOrInsert(model interface{}, conflict []string) (bool, error) {
+	if err := checkStruct("model", model); err != nil {
+		return false, err
+	}
+	if err := s.callHook(HookBeforeInsert, model); err != nil {
+		return false, err
+	}
+
+	val := reflect.ValueOf(model).Elem()
+	info := s.schema.parseType(val.Type())
+	setDiscriminator(info, val)
+	if err := validate(info, val, false); err != nil {
+		return false, err
+	}
+
+	if len(conflict) == 0 {
+		if info.PK == nil {
+			return false, fmt.Errorf("storm: GetOrInsert of %s needs conflict columns, it has no primary key", info.Type.Name())
+		}
+		conflict = []string{info.PK.Column}
+	}
+
+	// the existing row is found with the values of the conflict columns in model
+	existing := Eq{}
+	for _, col := range conflict {
+		field := info.field(col)
+		if field == nil {
+			return false, fmt.Errorf("storm: GetOrInsert conflict column %s is not a column of %s", col, info.Type.Name())
+		}
+		existing[col] = field.Value(val).Interface()
+	}
+
+	var columns []string
+	var placeholders []string
+	args := newParams()
+	for _, field := range info.Fields {
+		fieldVal := field.Value(val)
+		if (field.autoIncrement() && fieldVal.IsZero()) || !field.writable() {
+			continue
+		}
+		value, err := s.writeValue(field, fieldVal)
+		if err != nil {
+			return false, err
+		}
+		columns = append(columns, field.Column)
+		placeholders = append(placeholders, args.add(value))
+	}
+
+	clause, err := s.conflictClause(OnConflict{Columns: conflict, DoNothing: true}, info.Table, conflict, columns)
+	if err != nil {
+		return false, err
+	}
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) %s",
+		s.tableName(info.Table),
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+		clause,
+	)
+
+	inserted := false
+	if s.dialect.Name() == "mysql" {
+		res, err := s.exec(q, args.args...)
+		if err != nil {
+			return false, err
+		}
+		// 1 for an inserted row, 0 for a duplicate that was left as it is
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return false, err
+		}
+		inserted = affected == 1
+	} else {
+		query := s.From(model)
+		err = s.queryRows(q+" RETURNING *", args.args, func(rows *sql.Rows) error {
+			cols, err := rows.Columns()
+			if err != nil {
+				return err
+			}
+			if !rows.Next() {
+				return nil
+			}
+			vals, err := scanValues(rows, len(cols))
+			if err != nil {
+				return err
+			}
+			inserted = true
+			return query.mapRow(val, cols, vals, query.fieldsFor(val.Type(), cols), false)
+		})
+		if err != nil {
+			return false, err
+		}
+		if inserted || s.dryRun {
+			return inserted, s.callHook(HookAfterInsert, model)
+		}
+	}
+
+	// the row conflicted, or on mysql we don't know its generated values, so we load it
+	found, err := s.From(model).WhereExpr(existing).scanFirst(model)
+	if err != nil {
+		return false, err
+	}
+	if !found && !s.dryRun {
+		return false, fmt.Errorf("storm: GetOrInsert of %s conflicted but found no row with %v: %w", info.Type.Name(), map[string]interface{}(existing), ErrRecordNotFound)
+	}
+	if inserted {
+		return true, s.callHook(HookAfterInsert, model)
+	}
+	return false, s.callHook(HookAfterFind, model)
+}
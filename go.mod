@@ -2,4 +2,4 @@ module github.com/pepega90/storm
 
 go 1.24.0
 
-require github.com/lib/pq v1.10.9 // indirect
+require github.com/lib/pq v1.10.9
@@ -0,0 +1,73 @@
+package storm
+
+import "fmt"
+
+// GroupBy adds a GROUP BY clause with columns to the query, calling it again adds more columns.
+// Example: db.Table("orders").GroupBy("user_id").Select(&totals, "user_id", "SUM(total) AS total")
+func (q *Query) GroupBy(columns ...string) *Query {
+	q.groupBy = append(q.groupBy, columns...)
+	return q
+}
+
+// Having adds a HAVING condition with optional arguments to the query, it accepts the same
+// conditions as Where. Calling Having several times AND the conditions together.
+// Example: .GroupBy("user_id").Having("SUM(total) > $1", 1000)
+func (q *Query) Having(condition interface{}, args ...interface{}) *Query {
+	expr, err := q.storm.toExpr(condition, args...)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	q.having = append(q.having, expr)
+	return q
+}
+
+// HavingCount adds the condition `COUNT(*) op $n` to HAVING.
+// Example: .GroupBy("user_id").HavingCount(">", 5) is `HAVING COUNT(*) > $1`.
+func (q *Query) HavingCount(op string, value interface{}) *Query {
+	return q.havingAggregate("COUNT(*)", op, value)
+}
+
+// HavingSum adds the condition `SUM(column) op $n` to HAVING.
+func (q *Query) HavingSum(column, op string, value interface{}) *Query {
+	return q.havingColumn("SUM", column, op, value)
+}
+
+// HavingAvg adds the condition `AVG(column) op $n` to HAVING.
+func (q *Query) HavingAvg(column, op string, value interface{}) *Query {
+	return q.havingColumn("AVG", column, op, value)
+}
+
+// HavingMin adds the condition `MIN(column) op $n` to HAVING.
+func (q *Query) HavingMin(column, op string, value interface{}) *Query {
+	return q.havingColumn("MIN", column, op, value)
+}
+
+// HavingMax adds the condition `MAX(column) op $n` to HAVING.
+func (q *Query) HavingMax(column, op string, value interface{}) *Query {
+	return q.havingColumn("MAX", column, op, value)
+}
+
+// havingColumn adds `fn(column) op $n` to HAVING, column must be a plain (optionally qualified)
+// column name.
+func (q *Query) havingColumn(fn, column, op string, value interface{}) *Query {
+	if !isIdentifier(column) {
+		q.err = fmt.Errorf("storm: invalid column name %q in HAVING %s", column, fn)
+		return q
+	}
+	return q.havingAggregate(fn+"("+column+")", op, value)
+}
+
+// havingAggregate adds `aggregate op $n` to HAVING, op must be a comparison operator and the
+// columns of the aggregate are checked by havingColumn, so it can never inject SQL, the value
+// is always bound.
+func (q *Query) havingAggregate(aggregate, op string, value interface{}) *Query {
+	switch op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+	default:
+		q.err = fmt.Errorf("storm: invalid comparison operator %q in HAVING", op)
+		return q
+	}
+	q.having = append(q.having, rawExpr{aggregate + " " + op + " $1", []interface{}{value}})
+	return q
+}
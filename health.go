@@ -0,0 +1,124 @@
+package storm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// recentWindow is how far back HealthReport.RecentErrors looks.
+const recentWindow = time.Minute
+
+// HealthReport is a snapshot of the database connection and the statements of a Storm instance,
+// see Health. It has json tags so it can be returned as it is by a readiness endpoint.
+type HealthReport struct {
+	Healthy         bool          `json:"healthy"`                 // Healthy, the ping succeeded
+	Error           string        `json:"error,omitempty"`         // Error, of the ping, when it failed
+	PingLatency     time.Duration `json:"ping_latency"`            // PingLatency, how long the ping took, in nanoseconds in json
+	OpenConnections int           `json:"open_connections"`        // OpenConnections, in use and idle
+	InUse           int           `json:"in_use"`                  // InUse, connections currently in use
+	Idle            int           `json:"idle"`                    // Idle, connections waiting in the pool
+	MaxOpen         int           `json:"max_open"`                // MaxOpen, limit of open connections, 0 is unlimited
+	WaitCount       int64         `json:"wait_count"`              // WaitCount, times a statement waited for a free connection
+	WaitDuration    time.Duration `json:"wait_duration"`           // WaitDuration, total time spent waiting for a connection
+	Queries         uint64        `json:"queries"`                 // Queries, statements executed since New
+	Errors          uint64        `json:"errors"`                  // Errors, statements that failed since New
+	RecentErrors    int           `json:"recent_errors"`           // RecentErrors, statements that failed in the last minute
+	LastError       string        `json:"last_error,omitempty"`    // LastError, error of the last failed statement
+	LastErrorAt     *time.Time    `json:"last_error_at,omitempty"` // LastErrorAt, when the last statement failed
+}
+
+// Health pings the database with ctx and returns it together with the pool statistics and the
+// error counts of the statements, ready to plug into a readiness endpoint:
+//
+//	http.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
+//		report := db.Health(r.Context())
+//		if !report.Healthy {
+//			w.WriteHeader(http.StatusServiceUnavailable)
+//		}
+//		json.NewEncoder(w).Encode(report)
+//	})
+func (s *Storm) Health(ctx context.Context) HealthReport {
+	start := time.Now()
+	err := s.db.PingContext(ctx)
+
+	report := HealthReport{
+		Healthy:     err == nil,
+		PingLatency: time.Since(start),
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	pool := s.db.Stats()
+	report.OpenConnections = pool.OpenConnections
+	report.InUse = pool.InUse
+	report.Idle = pool.Idle
+	report.MaxOpen = pool.MaxOpenConnections
+	report.WaitCount = pool.WaitCount
+	report.WaitDuration = pool.WaitDuration
+
+	s.stats.fill(&report)
+	return report
+}
+
+// queryStats counts the statements and their errors, it is shared by every session.
+type queryStats struct {
+	queries atomic.Uint64
+	errors  atomic.Uint64
+
+	mu          sync.Mutex
+	buckets     [60]errorBucket // buckets, errors per second of the last minute, indexed by second % 60
+	lastError   string
+	lastErrorAt time.Time
+}
+
+// errorBucket, the number of errors of one second
+type errorBucket struct {
+	second int64
+	count  int
+}
+
+// record counts e, a statement that was executed.
+func (st *queryStats) record(e QueryEvent) {
+	if e.DryRun {
+		return
+	}
+	st.queries.Add(1)
+	if e.Err == nil {
+		return
+	}
+	st.errors.Add(1)
+
+	now := time.Now()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	b := &st.buckets[now.Unix()%int64(len(st.buckets))]
+	if b.second != now.Unix() {
+		*b = errorBucket{second: now.Unix()}
+	}
+	b.count++
+	st.lastError = e.Err.Error()
+	st.lastErrorAt = now
+}
+
+// fill sets the statement counters of report.
+func (st *queryStats) fill(report *HealthReport) {
+	report.Queries = st.queries.Load()
+	report.Errors = st.errors.Load()
+
+	since := time.Now().Add(-recentWindow).Unix()
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, b := range st.buckets {
+		if b.second > since {
+			report.RecentErrors += b.count
+		}
+	}
+	if !st.lastErrorAt.IsZero() {
+		at := st.lastErrorAt
+		report.LastError = st.lastError
+		report.LastErrorAt = &at
+	}
+}
@@ -0,0 +1,117 @@
+package storm
+
+// Model hooks, implement any of them on the model (pointer receiver) to run code
+// around the operations of storm. An error returned by a Before hook stops the operation,
+// an error returned by an After hook is returned to the caller.
+// Hooks are not called in a session with SkipHooks.
+type (
+	// BeforeInsertHook is called by Insert before the INSERT statement.
+	BeforeInsertHook interface {
+		BeforeInsert(s *Storm) error
+	}
+	// AfterInsertHook is called by Insert after the row was inserted.
+	AfterInsertHook interface {
+		AfterInsert(s *Storm) error
+	}
+	// BeforeUpdateHook is called by Update before the UPDATE statement.
+	BeforeUpdateHook interface {
+		BeforeUpdate(s *Storm) error
+	}
+	// AfterUpdateHook is called by Update after the row was updated.
+	AfterUpdateHook interface {
+		AfterUpdate(s *Storm) error
+	}
+	// BeforeDeleteHook is called by Delete before the DELETE statement.
+	BeforeDeleteHook interface {
+		BeforeDelete(s *Storm) error
+	}
+	// AfterDeleteHook is called by Delete after the row was deleted.
+	AfterDeleteHook interface {
+		AfterDelete(s *Storm) error
+	}
+	// AfterFindHook is called by First and Select for every struct scanned from the database.
+	AfterFindHook interface {
+		AfterFind(s *Storm) error
+	}
+)
+
+// HookKind is the moment of an operation a model hook or a plugin callback runs.
+type HookKind int
+
+const (
+	HookBeforeInsert HookKind = iota
+	HookAfterInsert
+	HookBeforeUpdate
+	HookAfterUpdate
+	HookBeforeDelete
+	HookAfterDelete
+	HookAfterFind
+)
+
+// isAfter reports whether the hook runs after the statement was executed.
+func (k HookKind) isAfter() bool {
+	return k != HookBeforeInsert && k != HookBeforeUpdate && k != HookBeforeDelete
+}
+
+// callHook calls the hook of kind on model, if model implements it, then the plugin
+// callbacks registered for kind. Model hooks are not called when the session skip hooks,
+// and nothing After is called in dry run, since nothing was written.
+// The change event of an After kind is emitted even when a hook fails, the row is written
+// already, but not for an Update or Delete that matched no row.
+func (s *Storm) callHook(kind HookKind, model interface{}) error {
+	if s.dryRun && kind.isAfter() {
+		return nil
+	}
+
+	var err error
+	if !s.skipHooks {
+		err = callModelHook(s, kind, model)
+	}
+
+	for _, fn := range s.callbacks.of(kind) {
+		if err != nil {
+			break
+		}
+		err = fn(s, model)
+	}
+
+	if kind.isAfter() && s.RowsAffected() != 0 {
+		s.emitChange(kind, model)
+	}
+	return err
+}
+
+// callModelHook calls the hook of kind on model, if model implements it.
+func callModelHook(s *Storm, kind HookKind, model interface{}) error {
+	switch kind {
+	case HookBeforeInsert:
+		if h, ok := model.(BeforeInsertHook); ok {
+			return h.BeforeInsert(s)
+		}
+	case HookBeforeUpdate:
+		if h, ok := model.(BeforeUpdateHook); ok {
+			return h.BeforeUpdate(s)
+		}
+	case HookBeforeDelete:
+		if h, ok := model.(BeforeDeleteHook); ok {
+			return h.BeforeDelete(s)
+		}
+	case HookAfterInsert:
+		if h, ok := model.(AfterInsertHook); ok {
+			return h.AfterInsert(s)
+		}
+	case HookAfterUpdate:
+		if h, ok := model.(AfterUpdateHook); ok {
+			return h.AfterUpdate(s)
+		}
+	case HookAfterDelete:
+		if h, ok := model.(AfterDeleteHook); ok {
+			return h.AfterDelete(s)
+		}
+	case HookAfterFind:
+		if h, ok := model.(AfterFindHook); ok {
+			return h.AfterFind(s)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// identityMap, the models loaded by primary key in a session with IdentityMap, one instance per row
+type identityMap struct {
+	mu     sync.Mutex
+	models map[identityKey]interface{} // models, pointer to the loaded struct
+}
+
+// identityKey, identify a row of a model type, the table tells apart the schemas of WithSchema
+type identityKey struct {
+	model reflect.Type
+	table string
+	id    string // id, the primary key formatted, so 42 and int64(42) are the same row
+
+	// the sessions derived with Masked or Unscoped share the identity map of their parent but
+	// don't see the same rows, a masked row or a soft deleted one is never given to the others
+	masked   bool
+	unscoped bool
+}
+
+// WithIdentityMap returns a session with its own identity map, a shortcut of
+// Session(&SessionConfig{IdentityMap: true}). Give it to one request: the rows it loads by
+// primary key are loaded once, and FindRef returns the same instance for every call:
+//
+//	tx := db.WithIdentityMap()
+//	a, _ := storm.FindRef[User](tx, 42) // SELECT ...
+//	b, _ := storm.FindRef[User](tx, 42) // no query, a == b
+//
+// Get copies the remembered row into its dest. Update, Delete and Upsert of a model forget its
+// row, DeleteWhere every row of the model, the other writes (builders, Exec) are not seen by the
+// identity map.
+func (s *Storm) WithIdentityMap() *Storm {
+	return s.Session(&SessionConfig{IdentityMap: true})
+}
+
+// FindRef loads the record of model T whose primary key equals id, like Find, and returns a
+// pointer to it. In a session with an identity map, see WithIdentityMap, every call for the
+// same row returns the same pointer and only the first one runs a query.
+func FindRef[T any](s *Storm, id interface{}) (*T, error) {
+	dest := new(T)
+	if s.identity != nil {
+		if model, ok := s.identity.get(s.identityKey(reflect.TypeOf(dest).Elem(), id)); ok {
+			return model.(*T), nil
+		}
+	}
+	if err := s.Get(dest, id); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+// identityKey returns the key of the row of model type t with primary key id.
+func (s *Storm) identityKey(t reflect.Type, id interface{}) identityKey {
+	return identityKey{
+		model: t,
+		table: s.tableName(s.schema.parseType(t).Table),
+		id:    fmt.Sprint(id),
+
+		masked:   s.masked,
+		unscoped: s.unscoped,
+	}
+}
+
+// forgetIdentity forgets the row of model, val is its struct value, after it was written.
+func (s *Storm) forgetIdentity(info *Schema, val reflect.Value) {
+	if s.identity == nil || info.PK == nil {
+		return
+	}
+	s.identity.forget(s.identityKey(info.Type, info.PK.Value(val).Interface()))
+}
+
+// get returns the remembered model of key.
+func (m *identityMap) get(key identityKey) (interface{}, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	model, ok := m.models[key]
+	return model, ok
+}
+
+// put remembers model, a pointer to struct, as the instance of key.
+func (m *identityMap) put(key identityKey, model interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.models[key] = model
+}
+
+// forget forgets the model of key, as seen by every session, masked or unscoped or not.
+func (m *identityMap) forget(key identityKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, masked := range []bool{false, true} {
+		for _, unscoped := range []bool{false, true} {
+			key.masked, key.unscoped = masked, unscoped
+			delete(m.models, key)
+		}
+	}
+}
+
+// forgetModel forgets every model of type t.
+func (m *identityMap) forgetModel(t reflect.Type) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key := range m.models {
+		if key.model == t {
+			delete(m.models, key)
+		}
+	}
+}
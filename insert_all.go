@@ -0,0 +1,136 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxInsertParams is the number of placeholders of a single INSERT of InsertAll, postgres and
+// mysql accept up to 65535, sqlite 32766 (since 3.32).
+const maxInsertParams = 32766
+
+// InsertAll inserts models, a slice (or pointer to slice) of struct or pointer to struct, with
+// multi-row INSERT statements, and writes the values generated by the database back into every
+// element, in order: the zero auto increment keys and the fields tagged generated, read with RETURNING.
+//
+//	users := []User{{Name: "aji"}, {Name: "dika"}}
+//	err := db.InsertAll(users) // users[0].ID and users[1].ID are set
+//
+// An auto increment key must be zero in every element (generated by the database) or set in
+// every element. Big slices are split in several statements, run InsertAll in a Transaction
+// to insert all of them or none.
+// mysql has no RETURNING, there the generated values are not written back.
+func (s *Storm) InsertAll(models interface{}) error {
+	list := reflect.ValueOf(models)
+	if list.Kind() == reflect.Ptr {
+		list = list.Elem()
+	}
+	if list.Kind() != reflect.Slice {
+		return fmt.Errorf("storm: InsertAll needs a slice of struct, got %T", models)
+	}
+	if list.Len() == 0 {
+		return nil
+	}
+
+	// rows, the struct value of every element, elements of a slice are addressable so the
+	// generated values can be set into them
+	rows := make([]reflect.Value, list.Len())
+	for i := range rows {
+		row := list.Index(i)
+		if row.Kind() == reflect.Ptr && !row.IsNil() {
+			row = row.Elem()
+		}
+		if row.Kind() != reflect.Struct {
+			return fmt.Errorf("storm: InsertAll needs a slice of struct, got %T", models)
+		}
+		rows[i] = row
+	}
+
+	for _, row := range rows {
+		if err := s.callHook(HookBeforeInsert, row.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+
+	info := s.schema.parseType(rows[0].Type())
+	for i, row := range rows {
+		setDiscriminator(info, row)
+		if err := validate(info, row, false); err != nil {
+			return fmt.Errorf("storm: InsertAll row %d: %w", i, err)
+		}
+	}
+
+	// fields, the columns we insert, returning the ones the database fills
+	var fields, returning []*SchemaField
+	for _, field := range info.Fields {
+		if !field.writable() {
+			continue
+		}
+		if field.autoIncrement() {
+			set := 0
+			for _, row := range rows {
+				if !field.Value(row).IsZero() {
+					set++
+				}
+			}
+			if set == 0 {
+				returning = append(returning, field)
+				continue
+			}
+			if set < len(rows) {
+				return fmt.Errorf("storm: InsertAll: %s is set in some rows only, set it in every row or in none", field.Name)
+			}
+		}
+		fields = append(fields, field)
+	}
+	returning = append(returning, info.generatedFields()...)
+
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field.Column
+	}
+
+	perStatement := len(rows)
+	if len(fields) > 0 {
+		perStatement = max(1, maxInsertParams/len(fields))
+	}
+	for start := 0; start < len(rows); start += perStatement {
+		chunk := rows[start:min(start+perStatement, len(rows))]
+
+		args := newParams()
+		values := make([]string, len(chunk))
+		for i, row := range chunk {
+			placeholders := make([]string, len(fields))
+			for j, field := range fields {
+				value, err := s.writeValue(field, field.Value(row))
+				if err != nil {
+					return err
+				}
+				placeholders[j] = args.add(value)
+			}
+			values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+		}
+
+		q := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+			s.tableName(info.Table),
+			strings.Join(columns, ", "),
+			strings.Join(values, ", "),
+		)
+
+		if len(returning) > 0 {
+			if _, err := s.execReturning(q, args.args, chunk, returning); err != nil {
+				return err
+			}
+		} else if _, err := s.exec(q, args.args...); err != nil {
+			return err
+		}
+	}
+
+	for _, row := range rows {
+		if err := s.callHook(HookAfterInsert, row.Addr().Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
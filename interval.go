@@ -0,0 +1,126 @@
+package storm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// bindArgs converts the arguments of a statement to what the database of dialect d expects.
+// On postgres a time.Duration is bound as interval text, so it can be written to an interval column,
+// the other databases have no interval type and store it as its number of nanoseconds.
+// A json.RawMessage is bound as text on postgres too, lib/pq would send it as bytea otherwise.
+func bindArgs(d Dialect, args []interface{}) []interface{} {
+	if d.Name() != "postgres" {
+		return args
+	}
+	var bound []interface{}
+	for i, arg := range args {
+		var value interface{}
+		switch v := arg.(type) {
+		case time.Duration:
+			value = formatInterval(v)
+		case json.RawMessage:
+			if v != nil {
+				value = string(v)
+			}
+		case *json.RawMessage:
+			if v != nil && *v != nil {
+				value = string(*v)
+			}
+		default:
+			continue
+		}
+		// copy before the first change, args can be the slice of the caller
+		if bound == nil {
+			bound = append([]interface{}(nil), args...)
+		}
+		bound[i] = value
+	}
+	if bound == nil {
+		return args
+	}
+	return bound
+}
+
+// formatInterval writes d as postgres interval input, with microsecond precision like interval itself.
+func formatInterval(d time.Duration) string {
+	return fmt.Sprintf("%d microseconds", d.Microseconds())
+}
+
+// parseInterval parses postgres interval output (the default "postgres" IntervalStyle), like
+// "1 year 2 mons 3 days 04:05:06.789" or "-1 days +02:00:00".
+// Years and months have no fixed length, they count as 365.25 and 30 days like postgres does for epoch.
+func parseInterval(s string) (time.Duration, error) {
+	var total time.Duration
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+
+		if strings.Contains(field, ":") {
+			d, err := parseClock(field)
+			if err != nil {
+				return 0, fmt.Errorf("storm: invalid interval %q: %v", s, err)
+			}
+			total += d
+			continue
+		}
+
+		if i+1 >= len(fields) {
+			return 0, fmt.Errorf("storm: invalid interval %q: %s has no unit", s, field)
+		}
+		n, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return 0, fmt.Errorf("storm: invalid interval %q: %v", s, err)
+		}
+
+		i++
+		var unit time.Duration
+		switch strings.TrimSuffix(fields[i], "s") {
+		case "year":
+			unit = time.Duration(365.25 * 24 * float64(time.Hour))
+		case "mon":
+			unit = 30 * 24 * time.Hour
+		case "day":
+			unit = 24 * time.Hour
+		default:
+			return 0, fmt.Errorf("storm: invalid interval %q: unknown unit %s", s, fields[i])
+		}
+		total += time.Duration(n * float64(unit))
+	}
+	return total, nil
+}
+
+// parseClock parses the time part of an interval, [+-]HH:MM:SS[.ffffff].
+func parseClock(s string) (time.Duration, error) {
+	sign := time.Duration(1)
+	if strings.HasPrefix(s, "-") {
+		sign = -1
+	}
+	s = strings.TrimLeft(s, "+-")
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return 0, fmt.Errorf("%s is not HH:MM:SS", s)
+	}
+	hours, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, err
+	}
+	minutes, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, err
+	}
+	seconds, err := strconv.ParseFloat(parts[2], 64)
+	if err != nil {
+		return 0, err
+	}
+
+	d := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds*float64(time.Second))
+	return sign * d, nil
+}
@@ -0,0 +1,57 @@
+package storm
+
+import "fmt"
+
+// JoinLateral joins the subquery sub as alias with JOIN LATERAL, sub can refer to the columns
+// of the tables before it, which makes top-N-per-group queries simple, like the latest order
+// of every user:
+//
+//	latest := db.Table("orders").
+//		Where("orders.user_id = users.id").
+//		OrderByDesc("created_at").
+//		Limit(1)
+//	db.From(&User{}).
+//		JoinLateral(latest, "o", "true").
+//		Select(&rows, "users.id", "users.name_user", "o.total AS last_total")
+//	// SELECT ... FROM users JOIN LATERAL (SELECT * FROM orders WHERE orders.user_id = users.id
+//	//   ORDER BY created_at DESC LIMIT $1) AS o ON true
+//
+// on is the join condition, "true" when the subquery is already correlated.
+// The placeholders of sub are merged with the ones of the query. LATERAL is supported by
+// postgres and mysql 8.0.14+, not by sqlite.
+func (q *Query) JoinLateral(sub *Query, alias, on string) *Query {
+	return q.joinLateral("JOIN", sub, alias, on)
+}
+
+// LeftJoinLateral is JoinLateral with LEFT JOIN, the rows the subquery returns nothing for
+// are kept with NULL columns, like the users without orders.
+func (q *Query) LeftJoinLateral(sub *Query, alias, on string) *Query {
+	return q.joinLateral("LEFT JOIN", sub, alias, on)
+}
+
+// joinLateral adds `kind LATERAL (sub) AS alias ON on` to the joins of the query.
+func (q *Query) joinLateral(kind string, sub *Query, alias, on string) *Query {
+	if q.storm.dialect.Name() == "sqlite3" {
+		q.err = fmt.Errorf("storm: LATERAL joins are not supported on sqlite")
+		return q
+	}
+	if !isIdentifier(alias) {
+		q.err = fmt.Errorf("storm: invalid alias %q for a LATERAL join", alias)
+		return q
+	}
+	if sub.err != nil {
+		q.err = sub.err
+		return q
+	}
+	if on == "" {
+		on = "true"
+	}
+
+	sql, args, err := sub.buildSelect(nil, sub.limit)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	q.joins = append(q.joins, rawExpr{fmt.Sprintf("%s LATERAL (%s) AS %s ON %s", kind, sql, alias, on), args})
+	return q
+}
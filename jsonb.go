@@ -0,0 +1,76 @@
+package storm
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// rawMessageType, json.RawMessage fields hold the json of a json/jsonb column as it is
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// JSON returns the postgres expression that extracts keys from the jsonb column with `->`,
+// the result is still jsonb. Example: JSON("metadata", "address", "city") is
+// `metadata->'address'->'city'`.
+// It can be used anywhere a column name is expected, like a key of Eq.
+func JSON(column string, keys ...string) string {
+	expr := column
+	for _, key := range keys {
+		expr += "->" + quoteLiteral(key)
+	}
+	return expr
+}
+
+// JSONText is like JSON, but the last key is extracted as text with `->>`, so it can be
+// compared with a string: Eq{JSONText("metadata", "plan"): "pro"} is `metadata->>'plan' = $1`.
+func JSONText(column string, keys ...string) string {
+	if len(keys) == 0 {
+		return column
+	}
+	return JSON(column, keys[:len(keys)-1]...) + "->>" + quoteLiteral(keys[len(keys)-1])
+}
+
+// quoteLiteral quotes s as a SQL string literal, doubling the quotes inside it.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// jsonContains, is the `@>` condition of JSONContains
+type jsonContains struct {
+	column string
+	value  interface{}
+}
+
+func (j jsonContains) ToSQL() (string, []interface{}, error) {
+	doc, err := json.Marshal(j.value)
+	if err != nil {
+		return "", nil, fmt.Errorf("storm: encode JSON value of %s: %v", j.column, err)
+	}
+	return fmt.Sprintf("%s @> $1::jsonb", j.column), []interface{}{string(doc)}, nil
+}
+
+// JSONContains is the condition that the jsonb column contains value, with the `@>` operator.
+// value is encoded to JSON, so JSONContains("metadata", map[string]interface{}{"plan": "pro"})
+// is `metadata @> $1::jsonb` with `{"plan":"pro"}`.
+func JSONContains(column string, value interface{}) Expr {
+	return jsonContains{column, value}
+}
+
+// JSONHasKey is the condition that the jsonb column has the top level key, with the `?` operator.
+// Example: JSONHasKey("metadata", "plan") is `metadata ? $1`.
+func JSONHasKey(column, key string) Expr {
+	return rawExpr{fmt.Sprintf("%s ? $1", column), []interface{}{key}}
+}
+
+// WhereJSONContains adds a JSONContains condition to the query.
+// Example: .WhereJSONContains("metadata", map[string]interface{}{"plan": "pro"})
+func (q *Query) WhereJSONContains(column string, value interface{}) *Query {
+	return q.WhereExpr(JSONContains(column, value))
+}
+
+// WhereJSONHasKey adds a JSONHasKey condition to the query.
+// Example: .WhereJSONHasKey("metadata", "plan")
+func (q *Query) WhereJSONHasKey(column, key string) *Query {
+	return q.WhereExpr(JSONHasKey(column, key))
+}
@@ -0,0 +1,104 @@
+package storm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Notification is a message received on a channel subscribed with Listen.
+type Notification struct {
+	Channel string // Channel, where the message was sent
+	Payload string // Payload, the message, can be empty
+	PID     int    // PID, process ID of the postgres backend that sent it
+}
+
+// Listen subscribes to channel with postgres LISTEN and delivers the messages on the returned channel
+// until ctx is done, then the subscription is closed and so is the returned channel.
+// LISTEN needs a dedicated connection, so storm opens one with the dsn given to New and
+// reconnects it automatically when it drops. Messages sent while reconnecting are lost.
+//
+//	notifications, err := db.Listen(ctx, "orders")
+//	for n := range notifications {
+//		fmt.Println(n.Payload)
+//	}
+func (s *Storm) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	if s.dialect.Name() != "postgres" {
+		return nil, fmt.Errorf("storm: Listen is only supported on postgres, not %s", s.dialect.Name())
+	}
+	if s.dsn == "" {
+		return nil, fmt.Errorf("storm: Listen needs the dsn, create storm with New instead of NewWithDB")
+	}
+
+	// the first event tells whether the connection could be established, pq keeps
+	// retrying on its own, so without it a wrong dsn would block Listen forever.
+	connected := make(chan error, 1)
+	listener := pq.NewListener(s.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventConnected:
+			err = nil
+		case pq.ListenerEventConnectionAttemptFailed:
+		default:
+			return
+		}
+		select {
+		case connected <- err:
+		default:
+		}
+	})
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			listener.Close()
+			return nil, fmt.Errorf("storm: listen %s: %v", channel, err)
+		}
+	case <-ctx.Done():
+		listener.Close()
+		return nil, ctx.Err()
+	}
+
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("storm: listen %s: %v", channel, err)
+	}
+
+	out := make(chan Notification)
+	go func() {
+		defer close(out)
+		defer listener.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case n, ok := <-listener.Notify:
+				if !ok {
+					return
+				}
+				// pq sends nil after a reconnection, there is no message in it
+				if n == nil {
+					continue
+				}
+				select {
+				case out <- Notification{Channel: n.Channel, Payload: n.Extra, PID: n.BePid}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Notify sends payload to every listener of channel with pg_notify.
+// Inside a transaction the message is delivered when the transaction commits.
+func (s *Storm) Notify(channel, payload string) error {
+	if s.dialect.Name() != "postgres" {
+		return fmt.Errorf("storm: Notify is only supported on postgres, not %s", s.dialect.Name())
+	}
+	_, err := s.exec("SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
@@ -0,0 +1,59 @@
+package storm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// LockRecord locks the row of model, found by its primary key, until the end of the current
+// transaction with `SELECT ... FOR UPDATE NOWAIT`, and reloads model from it, so the critical
+// section works on the latest values and no other transaction changes the row meanwhile:
+//
+//	err := db.Transaction(func(tx *storm.Tx) error {
+//		account := Account{ID: id}
+//		if err := tx.LockRecord(ctx, &account); err != nil {
+//			return err // errors.Is(err, storm.ErrLocked) when another transaction holds it
+//		}
+//		account.Balance -= amount
+//		_, err := tx.Update(&account)
+//		return err
+//	})
+//
+// It doesn't wait for a lock held by another transaction, it returns ErrLocked right away, and
+// ErrRecordNotFound when no row matches. It must run in a transaction, the lock is released on
+// commit or rollback. A nil ctx keeps the context of s. sqlite locks the whole database and
+// has no row locks, so it is not supported there.
+func (s *Storm) LockRecord(ctx context.Context, model interface{}) error {
+	if err := checkStruct("model", model); err != nil {
+		return err
+	}
+	if !s.inTx() && !s.dryRun {
+		return fmt.Errorf("storm: LockRecord must run in a transaction, the lock is released at its end")
+	}
+	if s.dialect.Name() == "sqlite3" {
+		return fmt.Errorf("storm: LockRecord is not supported on sqlite3, it has no row locks")
+	}
+	if ctx != nil {
+		s = s.WithContext(ctx)
+	}
+
+	val := reflect.ValueOf(model).Elem()
+	info := s.schema.parseType(val.Type())
+	if info.PK == nil {
+		return fmt.Errorf("storm: %s has no primary key", info.Type.Name())
+	}
+
+	q := s.From(model).WhereExpr(Eq{info.PK.Column: info.PK.Value(val).Interface()})
+	q.lock = "FOR UPDATE NOWAIT"
+	found, err := q.scanFirst(model)
+	if err != nil {
+		return err
+	}
+	if !found && !s.dryRun {
+		return ErrRecordNotFound
+	}
+	// the remembered row may be older than the one we just locked
+	s.forgetIdentity(info, val)
+	return s.callHook(HookAfterFind, model)
+}
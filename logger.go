@@ -0,0 +1,54 @@
+package storm
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// QueryEvent describes one statement executed (or, in dry run, only built) by storm.
+type QueryEvent struct {
+	SQL      string            // SQL, the statement as sent to the database
+	Args     []interface{}     // Args, the arguments bound to the statement
+	Duration time.Duration     // Duration, how long the database took, zero in dry run
+	Err      error             // Err, the error returned by the database, if any
+	DryRun   bool              // DryRun, true when the statement was not sent to the database
+	Tags     map[string]string // Tags, key/values of the query or the session, like {"endpoint": "GET /users"}, see Query.Tag
+}
+
+// Logger receives every statement storm executes, set it with SessionConfig.Logger.
+type Logger interface {
+	LogQuery(ctx context.Context, e QueryEvent)
+}
+
+// stdLogger, is Logger that write to the standard library *log.Logger
+type stdLogger struct {
+	l *log.Logger
+}
+
+// NewStdLogger returns a Logger that prints every statement to l, or to the
+// standard logger of the log package when l is nil.
+func NewStdLogger(l *log.Logger) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return stdLogger{l: l}
+}
+
+func (s stdLogger) LogQuery(_ context.Context, e QueryEvent) {
+	// tags are printed as a map, its keys are sorted
+	tags := ""
+	if len(e.Tags) > 0 {
+		tags = fmt.Sprintf(" %v", e.Tags)
+	}
+
+	switch {
+	case e.DryRun:
+		s.l.Printf("[storm] [dry run] %s %v%s", e.SQL, e.Args, tags)
+	case e.Err != nil:
+		s.l.Printf("[storm] [%s] %s %v%s error: %v", e.Duration, e.SQL, e.Args, tags, e.Err)
+	default:
+		s.l.Printf("[storm] [%s] %s %v%s", e.Duration, e.SQL, e.Args, tags)
+	}
+}
@@ -0,0 +1,120 @@
+package storm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Manager holds several named Storm instances, like "primary", "analytics" and "legacy", for
+// applications working with several databases. Instances are resolved by name, or by model
+// once the model is bound to a name, so the code using a model doesn't need to know where it lives:
+//
+//	m := storm.NewManager()
+//	m.Add("primary", primary)
+//	m.Add("analytics", analytics)
+//	m.Bind("analytics", &PageView{}, &Event{})
+//
+//	db, err := m.For(&PageView{}) // analytics
+//	db, err = m.For(&User{})      // primary, the default
+//
+// The first added instance is the default, used for the models that are not bound, see SetDefault.
+// A Manager is safe for concurrent use.
+type Manager struct {
+	mu       sync.RWMutex
+	dbs      map[string]*Storm
+	models   map[reflect.Type]string // models, name of the instance of every bound model type
+	fallback string                  // fallback, name of the default instance
+}
+
+// NewManager creates an empty Manager.
+func NewManager() *Manager {
+	return &Manager{dbs: map[string]*Storm{}, models: map[reflect.Type]string{}}
+}
+
+// Add registers db as name, replacing the instance already registered as name.
+func (m *Manager) Add(name string, db *Storm) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dbs[name] = db
+	if m.fallback == "" {
+		m.fallback = name
+	}
+}
+
+// SetDefault sets the instance used for the models that are not bound.
+func (m *Manager) SetDefault(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fallback = name
+}
+
+// Bind binds models to the instance name, For returns it for them.
+func (m *Manager) Bind(name string, models ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, model := range models {
+		m.models[modelType(model)] = name
+	}
+}
+
+// Get returns the instance registered as name.
+func (m *Manager) Get(name string) (*Storm, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	db, ok := m.dbs[name]
+	if !ok {
+		return nil, fmt.Errorf("storm: no database named %q", name)
+	}
+	return db, nil
+}
+
+// For returns the instance model is bound to, or the default one. model can be a struct,
+// a pointer to struct or a (pointer to) slice of struct, like the models given to Bind.
+func (m *Manager) For(model interface{}) (*Storm, error) {
+	m.mu.RLock()
+	name, ok := m.models[modelType(model)]
+	if !ok {
+		name = m.fallback
+	}
+	m.mu.RUnlock()
+	if name == "" {
+		return nil, fmt.Errorf("storm: no database for %T, the manager is empty", model)
+	}
+	return m.Get(name)
+}
+
+// Health returns the HealthReport of every instance, by name.
+func (m *Manager) Health(ctx context.Context) map[string]HealthReport {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	reports := make(map[string]HealthReport, len(m.dbs))
+	for name, db := range m.dbs {
+		reports[name] = db.Health(ctx)
+	}
+	return reports
+}
+
+// Close closes every instance and returns their errors joined.
+func (m *Manager) Close() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var errs []error
+	for name, db := range m.dbs {
+		if err := db.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("storm: close %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// modelType returns the type model is bound by, the struct type behind pointers and slices.
+func modelType(model interface{}) reflect.Type {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,66 @@
+package storm
+
+import "strings"
+
+// Masked returns a session that masks the fields tagged with mask when it reads rows,
+// a shortcut of Session(&SessionConfig{Mask: true}). Give it to the code paths that must not
+// see the sensitive values, like support tools, while the privileged code keep reading the raw values:
+//
+//	type Customer struct {
+//		ID    int    `storm:"pk"`
+//		Email string `storm:"mask:email"` // dika@gmail.com -> d***@gmail.com
+//		Phone string `storm:"mask:phone"` // +62812345678 -> ********5678
+//		Notes string `storm:"mask"`       // anything -> ****
+//	}
+//	err := db.Masked().Get(&customer, 42)
+//
+// A masked model doesn't hold the real values anymore, so don't write it back with Update.
+func (s *Storm) Masked() *Storm {
+	return s.Session(&SessionConfig{Mask: true})
+}
+
+// maskValue masks value, scanned from the column of field, with the mask of field,
+// fields without mask and values that are not text are returned as they are.
+func maskValue(field *SchemaField, value interface{}) interface{} {
+	kind, ok := field.Tag["mask"]
+	if !ok {
+		return value
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return value
+	}
+	return mask(kind, text)
+}
+
+// mask masks text with kind, the value of the mask tag:
+// email keep the first letter and the domain, phone keep the last 4 digits,
+// anything else hide the whole text (without telling its length).
+func mask(kind, text string) string {
+	if text == "" {
+		return text
+	}
+
+	switch kind {
+	case "email":
+		at := strings.LastIndex(text, "@")
+		if at < 1 {
+			return "****"
+		}
+		return text[:1] + "***" + text[at:]
+	case "phone":
+		runes := []rune(text)
+		if len(runes) <= 4 {
+			return "****"
+		}
+		return strings.Repeat("*", len(runes)-4) + string(runes[len(runes)-4:])
+	default:
+		return "****"
+	}
+}
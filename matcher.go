@@ -0,0 +1,25 @@
+package storm
+
+import "strings"
+
+// ColumnMatcher reports whether the column of a result set, that has no field with exactly
+// its name, belongs to field. It is the last chance of a column before it is dropped.
+type ColumnMatcher func(column string, field *SchemaField) bool
+
+// DefaultColumnMatcher match a column with a field when, ignoring case, it equals the column
+// of the field, the field name, or the snake_case of the field name. So UserID is filled by
+// a column named user_id, UserId or USERID whatever the naming strategy.
+func DefaultColumnMatcher(column string, field *SchemaField) bool {
+	return strings.EqualFold(column, field.Column) ||
+		strings.EqualFold(column, field.Name) ||
+		strings.EqualFold(column, toSnakeCase(field.Name))
+}
+
+// WithColumnMatcher sets how the columns without exactly named field are matched with the
+// fields when scanning, DefaultColumnMatcher is used without this option. nil turns matching
+// off, only exact names are used then, like in Strict queries.
+func WithColumnMatcher(m ColumnMatcher) Option {
+	return func(s *Storm) {
+		s.matcher = m
+	}
+}
@@ -0,0 +1,67 @@
+package storm
+
+import "fmt"
+
+// CreateMaterializedView creates the postgres materialized view of model, named as the table of model,
+// from the SELECT query, so it can be queried like any other model with From:
+//
+//	type SalesReport struct {
+//		Day   time.Time `storm:"pk"`
+//		Total float64
+//	}
+//
+//	err := db.CreateMaterializedView(&SalesReport{}, "SELECT created_at::date AS day, SUM(total) AS total FROM orders GROUP BY 1")
+//	err = db.From(&SalesReport{}).Select(&reports)
+//
+// When model has a primary key, a unique index is created on it too, as required to refresh concurrently.
+// Nothing is done if the view already exists.
+func (s *Storm) CreateMaterializedView(model interface{}, query string) error {
+	info, err := s.materializedView(model)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.exec(fmt.Sprintf("CREATE MATERIALIZED VIEW IF NOT EXISTS %s AS %s", s.tableName(info.Table), query)); err != nil {
+		return err
+	}
+	if info.PK == nil {
+		return nil
+	}
+	_, err = s.exec(fmt.Sprintf("CREATE UNIQUE INDEX IF NOT EXISTS %s_pkey ON %s (%s)", info.Table, s.tableName(info.Table), info.PK.Column))
+	return err
+}
+
+// RefreshMaterializedView runs the query of the materialized view of model again.
+// With concurrently, reads are not blocked while it refreshes, this needs a unique index on
+// the view, which CreateMaterializedView creates for models with a primary key.
+func (s *Storm) RefreshMaterializedView(model interface{}, concurrently bool) error {
+	info, err := s.materializedView(model)
+	if err != nil {
+		return err
+	}
+
+	q := "REFRESH MATERIALIZED VIEW "
+	if concurrently {
+		q += "CONCURRENTLY "
+	}
+	_, err = s.exec(q + s.tableName(info.Table))
+	return err
+}
+
+// DropMaterializedView drops the materialized view of model, if it exists.
+func (s *Storm) DropMaterializedView(model interface{}) error {
+	info, err := s.materializedView(model)
+	if err != nil {
+		return err
+	}
+	_, err = s.exec(fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", s.tableName(info.Table)))
+	return err
+}
+
+// materializedView returns the metadata of the model of a materialized view.
+func (s *Storm) materializedView(model interface{}) (*Schema, error) {
+	if s.dialect.Name() != "postgres" {
+		return nil, fmt.Errorf("storm: materialized views are only supported on postgres, not %s", s.dialect.Name())
+	}
+	return s.schema.parseModel(model)
+}
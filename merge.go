@@ -0,0 +1,219 @@
+package storm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// MergeAction is what a WHEN clause of a MERGE does with a row, see MergeUpdate, MergeInsert,
+// MergeDelete and MergeDoNothing.
+type MergeAction struct {
+	sql     string // sql, of the action, like "UPDATE SET balance = s.balance"
+	matched bool   // matched, the action is for matched rows (UPDATE, DELETE)
+	any     bool   // any, the action is for matched and not matched rows (DO NOTHING)
+}
+
+// MergeUpdate updates the matched target row, set is the SQL expression of every updated
+// column, usually a column of the source: {"balance": "s.balance"}.
+func MergeUpdate(set map[string]string) MergeAction {
+	columns := sortedKeys(set)
+	assignments := make([]string, len(columns))
+	for i, col := range columns {
+		assignments[i] = fmt.Sprintf("%s = %s", col, set[col])
+	}
+	return MergeAction{sql: "UPDATE SET " + strings.Join(assignments, ", "), matched: true}
+}
+
+// MergeInsert inserts the source row that matched no target row, values is the SQL expression
+// of every inserted column, usually a column of the source: {"id": "s.id", "balance": "s.balance"}.
+func MergeInsert(values map[string]string) MergeAction {
+	columns := sortedKeys(values)
+	exprs := make([]string, len(columns))
+	for i, col := range columns {
+		exprs[i] = values[col]
+	}
+	return MergeAction{sql: fmt.Sprintf("INSERT (%s) VALUES (%s)", strings.Join(columns, ", "), strings.Join(exprs, ", "))}
+}
+
+// MergeDelete deletes the matched target row.
+func MergeDelete() MergeAction {
+	return MergeAction{sql: "DELETE", matched: true}
+}
+
+// MergeDoNothing leaves the row alone, it works for matched and not matched rows.
+func MergeDoNothing() MergeAction {
+	return MergeAction{sql: "DO NOTHING", any: true}
+}
+
+// sortedKeys returns the keys of m sorted, so the statements built from a map are always the same.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeClause, is one WHEN [NOT] MATCHED [AND condition] THEN action of a MERGE
+type mergeClause struct {
+	matched   bool
+	condition string
+	action    MergeAction
+}
+
+// MergeBuilder builds a MERGE statement, which synchronizes a target table with a source in
+// a single statement, see Merge.
+type MergeBuilder struct {
+	storm       *Storm
+	target      string
+	alias       string        // alias, of the target, optional
+	source      string        // source, table name or (subquery)
+	sourceAlias string        // sourceAlias, name the clauses use for the source rows
+	sourceArgs  []interface{} // sourceArgs, arguments of the subquery source
+	on          rawExpr
+	clauses     []mergeClause
+	err         error
+}
+
+// Merge starts a MERGE statement into target, for bulk synchronization jobs:
+//
+//	affected, err := db.Merge("accounts").
+//		Using("staging_accounts", "s").
+//		On("accounts.id = s.id").
+//		WhenMatchedAnd("s.closed", storm.MergeDelete()).
+//		WhenMatched(storm.MergeUpdate(map[string]string{"balance": "s.balance"})).
+//		WhenNotMatched(storm.MergeInsert(map[string]string{"id": "s.id", "balance": "s.balance"})).
+//		Exec()
+//
+// The WHEN clauses are checked in order, the first one whose condition holds applies.
+// MERGE is supported by postgres 15+, not by mysql and sqlite.
+func (s *Storm) Merge(target string) *MergeBuilder {
+	return &MergeBuilder{storm: s, target: s.tableName(target)}
+}
+
+// As names the target table alias in the conditions and actions.
+func (b *MergeBuilder) As(alias string) *MergeBuilder {
+	if !isIdentifier(alias) {
+		b.err = fmt.Errorf("storm: invalid alias %q for a MERGE target", alias)
+		return b
+	}
+	b.alias = alias
+	return b
+}
+
+// Using sets the source of the rows, source is a table name or a *Query whose rows are used
+// (its placeholders are merged with the ones of the statement), alias is how the conditions and
+// the actions name it.
+func (b *MergeBuilder) Using(source interface{}, alias string) *MergeBuilder {
+	if !isIdentifier(alias) {
+		b.err = fmt.Errorf("storm: invalid alias %q for a MERGE source", alias)
+		return b
+	}
+	b.sourceAlias = alias
+
+	switch src := source.(type) {
+	case string:
+		b.source = b.storm.tableName(src)
+	case *Query:
+		if src.err != nil {
+			b.err = src.err
+			return b
+		}
+		sql, args, err := src.buildSelect(nil, src.limit)
+		if err != nil {
+			b.err = err
+			return b
+		}
+		b.source = "(" + sql + ")"
+		b.sourceArgs = args
+	default:
+		b.err = fmt.Errorf("storm: MERGE source must be a table name or a *Query, got %T", source)
+	}
+	return b
+}
+
+// On sets the join condition of the target and source rows, its placeholders are numbered
+// from $1 like Where.
+func (b *MergeBuilder) On(condition string, args ...interface{}) *MergeBuilder {
+	b.on = rawExpr{condition, args}
+	return b
+}
+
+// WhenMatched adds the action for the target rows the source matched, MergeUpdate, MergeDelete
+// or MergeDoNothing.
+func (b *MergeBuilder) WhenMatched(action MergeAction) *MergeBuilder {
+	return b.when(true, "", action)
+}
+
+// WhenMatchedAnd is WhenMatched only for the rows where condition holds, like "s.closed".
+func (b *MergeBuilder) WhenMatchedAnd(condition string, action MergeAction) *MergeBuilder {
+	return b.when(true, condition, action)
+}
+
+// WhenNotMatched adds the action for the source rows that matched no target row, MergeInsert
+// or MergeDoNothing.
+func (b *MergeBuilder) WhenNotMatched(action MergeAction) *MergeBuilder {
+	return b.when(false, "", action)
+}
+
+// WhenNotMatchedAnd is WhenNotMatched only for the rows where condition holds.
+func (b *MergeBuilder) WhenNotMatchedAnd(condition string, action MergeAction) *MergeBuilder {
+	return b.when(false, condition, action)
+}
+
+// when adds a WHEN clause, checking that action can be used for matched (or not matched) rows.
+func (b *MergeBuilder) when(matched bool, condition string, action MergeAction) *MergeBuilder {
+	if !action.any && action.matched != matched {
+		kind := "WHEN NOT MATCHED"
+		if matched {
+			kind = "WHEN MATCHED"
+		}
+		b.err = fmt.Errorf("storm: %s can't %s", kind, strings.Fields(action.sql)[0])
+		return b
+	}
+	b.clauses = append(b.clauses, mergeClause{matched: matched, condition: condition, action: action})
+	return b
+}
+
+// Exec executes the MERGE statement and returns the number of target rows inserted, updated
+// or deleted.
+func (b *MergeBuilder) Exec() (int64, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if dialect := b.storm.dialect.Name(); dialect != "postgres" {
+		return 0, fmt.Errorf("storm: MERGE is not supported on %s", dialect)
+	}
+	if b.source == "" || b.on.sql == "" || len(b.clauses) == 0 {
+		return 0, fmt.Errorf("storm: merge into %s needs Using, On and at least one WHEN clause", b.target)
+	}
+
+	var q strings.Builder
+	q.WriteString("MERGE INTO " + b.target)
+	if b.alias != "" {
+		q.WriteString(" AS " + b.alias)
+	}
+	// the placeholders of the condition come after the ones of the source subquery
+	fmt.Fprintf(&q, " USING %s AS %s ON %s", b.source, b.sourceAlias, shiftPlaceholders(b.on.sql, len(b.sourceArgs)))
+
+	for _, c := range b.clauses {
+		q.WriteString(" WHEN ")
+		if !c.matched {
+			q.WriteString("NOT ")
+		}
+		q.WriteString("MATCHED")
+		if c.condition != "" {
+			q.WriteString(" AND " + c.condition)
+		}
+		q.WriteString(" THEN " + c.action.sql)
+	}
+
+	args := append(append([]interface{}(nil), b.sourceArgs...), b.on.args...)
+	res, err := b.storm.exec(q.String(), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,176 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// AutoMigrate creates the table of every model that doesn't exist yet.
+// Column types are derived from the Go types, `storm:"type:VARCHAR(100)"` overrides it,
+// and the primary key is auto incremented when it is an integer.
+// Existing tables are left as they are, AutoMigrate never alters or drops anything.
+//
+// A field tagged with `storm:"partition:range"` or `storm:"partition:list"` makes the table
+// a postgres partitioned table (PARTITION BY RANGE / LIST of that column), see CreateRangePartition.
+func (s *Storm) AutoMigrate(models ...interface{}) error {
+	for _, model := range models {
+		info, err := s.schema.parseModel(model)
+		if err != nil {
+			return err
+		}
+
+		ddl, err := s.createTableSQL(info)
+		if err != nil {
+			return err
+		}
+		if _, err := s.exec(ddl); err != nil {
+			return fmt.Errorf("storm: create table %s: %v", info.Table, err)
+		}
+	}
+	return nil
+}
+
+// createTableSQL builds the CREATE TABLE IF NOT EXISTS statement of the model described by info.
+func (s *Storm) createTableSQL(info *Schema) (string, error) {
+	partition, err := partitionOf(info)
+	if err != nil {
+		return "", err
+	}
+	if partition != nil && s.dialect.Name() != "postgres" {
+		return "", fmt.Errorf("storm: partitioned tables are only supported on postgres")
+	}
+
+	var columns []string
+	for _, field := range info.Fields {
+		colType, err := s.columnType(field, partition == nil)
+		if err != nil {
+			return "", err
+		}
+		columns = append(columns, field.Column+" "+colType)
+	}
+
+	// the primary key of a partitioned table must contain the partition column
+	if partition != nil && info.PK != nil {
+		pk := []string{info.PK.Column}
+		if partition.field != info.PK {
+			pk = append(pk, partition.field.Column)
+		}
+		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(pk, ", ")))
+	}
+
+	ddl := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", s.tableName(info.Table), strings.Join(columns, ", "))
+	if partition != nil {
+		ddl += fmt.Sprintf(" PARTITION BY %s (%s)", partition.strategy, partition.field.Column)
+	}
+	return ddl, nil
+}
+
+// columnType returns the SQL type of field for the dialect, inlinePK tells whether the
+// primary key constraint is declared on the column itself.
+func (s *Storm) columnType(field *SchemaField, inlinePK bool) (string, error) {
+	dialect := s.dialect.Name()
+
+	if field.PK && inlinePK && field.autoIncrement() && isInteger(field.Type) {
+		switch dialect {
+		case "mysql":
+			return "BIGINT AUTO_INCREMENT PRIMARY KEY", nil
+		case "sqlite3":
+			return "INTEGER PRIMARY KEY AUTOINCREMENT", nil
+		default:
+			return "BIGSERIAL PRIMARY KEY", nil
+		}
+	}
+
+	colType, ok := field.Tag["type"]
+	if !ok || colType == "" {
+		colType = sqlType(field.Type, dialect)
+	}
+	if colType == "" {
+		return "", fmt.Errorf("storm: no column type for field %s of type %v, set it with `storm:\"type:...\"`", field.Name, field.Type)
+	}
+
+	if field.PK {
+		if !inlinePK {
+			// the constraint is declared at the end of the table, but it still needs its sequence
+			if field.autoIncrement() && isInteger(field.Type) && colType == sqlType(field.Type, dialect) {
+				colType = "BIGSERIAL"
+			}
+			return colType, nil
+		}
+		colType += " PRIMARY KEY"
+	}
+	return colType, nil
+}
+
+// sqlType maps the Go type t to the column type of dialect, empty if there is no obvious one.
+func sqlType(t reflect.Type, dialect string) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == durationType {
+		if dialect == "postgres" {
+			return "INTERVAL"
+		}
+		return "BIGINT"
+	}
+
+	if t == rawMessageType {
+		switch dialect {
+		case "postgres":
+			return "JSONB"
+		case "mysql":
+			return "JSON"
+		default:
+			return "TEXT"
+		}
+	}
+
+	if t == reflect.TypeOf(time.Time{}) {
+		if dialect == "postgres" {
+			return "TIMESTAMP"
+		}
+		return "DATETIME"
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return "BOOLEAN"
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "INTEGER"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32:
+		return "REAL"
+	case reflect.Float64:
+		if dialect == "mysql" {
+			return "DOUBLE"
+		}
+		return "DOUBLE PRECISION"
+	case reflect.String:
+		if dialect == "mysql" {
+			return "VARCHAR(255)"
+		}
+		return "TEXT"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if dialect == "postgres" {
+				return "BYTEA"
+			}
+			return "BLOB"
+		}
+	}
+	return ""
+}
+
+// isInteger reports whether t is an integer type.
+func isInteger(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,243 @@
+// Package migrate runs versioned SQL migrations with storm.
+//
+// A migration is a pair of files in a directory, named after its version and a name:
+//
+//	20250301120000_create_users.up.sql
+//	20250301120000_create_users.down.sql
+//
+// Up applies the pending migrations in version order, each one in its own transaction, and
+// records it in the storm_migrations table. Down reverts the last applied ones.
+package migrate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pepega90/storm"
+)
+
+// table is where the applied migrations are recorded.
+const table = "storm_migrations"
+
+// fileName matches the file of a migration: version, name and direction.
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is one versioned migration found in the migrations directory.
+type Migration struct {
+	Version string
+	Name    string
+	Up      string // Up, path of the .up.sql file
+	Down    string // Down, path of the .down.sql file, empty if there is none
+}
+
+// Status is a migration together with whether it was applied.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time // AppliedAt, zero when not applied
+}
+
+// record, is a row of the storm_migrations table
+type record struct {
+	Version   string    `storm:"column:version"`
+	AppliedAt time.Time `storm:"column:applied_at"`
+}
+
+// Migrator applies the migrations of a directory to a database.
+type Migrator struct {
+	db  *storm.Storm
+	dir string
+}
+
+// New returns a Migrator for the migrations in dir.
+func New(db *storm.Storm, dir string) *Migrator {
+	return &Migrator{db: db, dir: dir}
+}
+
+// Migrations returns the migrations of the directory, sorted by version.
+func (m *Migrator) Migrations() ([]Migration, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, fmt.Errorf("migrate: read %s: %v", m.dir, err)
+	}
+
+	byVersion := map[string]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, name, direction := match[1], match[2], match[3]
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: name}
+			byVersion[version] = mig
+		} else if mig.Name != name {
+			return nil, fmt.Errorf("migrate: version %s is used by %s and %s", version, mig.Name, name)
+		}
+
+		path := filepath.Join(m.dir, entry.Name())
+		if direction == "up" {
+			mig.Up = path
+		} else {
+			mig.Down = path
+		}
+	}
+
+	var result []Migration
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migrate: %s_%s has no .up.sql file", mig.Version, mig.Name)
+		}
+		result = append(result, *mig)
+	}
+	// versions are timestamps of the same length, but compare them as numbers anyway
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Version, result[j].Version
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+	return result, nil
+}
+
+// Status returns every migration of the directory and whether it was applied.
+func (m *Migrator) Status() ([]Status, error) {
+	migrations, err := m.Migrations()
+	if err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, len(migrations))
+	for i, mig := range migrations {
+		at, ok := applied[mig.Version]
+		result[i] = Status{Migration: mig, Applied: ok, AppliedAt: at}
+	}
+	return result, nil
+}
+
+// Up applies every pending migration in version order and returns the applied ones.
+// It stops at the first failing migration, which is rolled back, the previous ones stay applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, st := range status {
+		if st.Applied {
+			continue
+		}
+		if err := m.run(st.Migration, st.Up, true); err != nil {
+			return done, err
+		}
+		done = append(done, st.Migration)
+	}
+	return done, nil
+}
+
+// Down reverts the last steps applied migrations, newest first, and returns the reverted ones.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for i := len(status) - 1; i >= 0 && len(done) < steps; i-- {
+		st := status[i]
+		if !st.Applied {
+			continue
+		}
+		if st.Down == "" {
+			return done, fmt.Errorf("migrate: %s_%s has no .down.sql file", st.Version, st.Name)
+		}
+		if err := m.run(st.Migration, st.Down, false); err != nil {
+			return done, err
+		}
+		done = append(done, st.Migration)
+	}
+	return done, nil
+}
+
+// run executes the SQL file at path in a transaction, and records (up) or forgets (down) mig.
+func (m *Migrator) run(mig Migration, path string, up bool) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("migrate: read %s: %v", path, err)
+	}
+
+	err = m.db.Transaction(func(tx *storm.Tx) error {
+		if strings.TrimSpace(string(content)) != "" {
+			if _, err := tx.SQLTx().ExecContext(tx.Context(), string(content)); err != nil {
+				return err
+			}
+		}
+
+		// the bookkeeping table is not part of any tenant, the global scopes don't apply to it
+		records := tx.Unscoped()
+		if up {
+			_, err := records.InsertInto(table).Columns("version", "applied_at").Values(mig.Version, time.Now()).Exec()
+			return err
+		}
+		_, err := records.DeleteFrom(table).Where("version = $1", mig.Version).Exec()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("migrate: %s_%s: %w", mig.Version, mig.Name, err)
+	}
+	return nil
+}
+
+// applied returns when every applied migration was applied, by version.
+func (m *Migrator) applied() (map[string]time.Time, error) {
+	_, err := m.db.DB().ExecContext(m.db.Context(), fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version VARCHAR(255) PRIMARY KEY, applied_at TIMESTAMP NOT NULL)", table))
+	if err != nil {
+		return nil, fmt.Errorf("migrate: create %s table: %v", table, err)
+	}
+
+	var records []record
+	if err := m.db.Unscoped().Table(table).Select(&records); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]time.Time, len(records))
+	for _, r := range records {
+		result[r.Version] = r.AppliedAt
+	}
+	return result, nil
+}
+
+// Create writes the empty up and down files of a new migration called name in dir,
+// versioned with the current time, and returns their paths.
+func Create(dir, name string) (up, down string, err error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if name == "" {
+		return "", "", fmt.Errorf("migrate: migration needs a name")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", "", err
+	}
+
+	base := filepath.Join(dir, time.Now().UTC().Format("20060102150405")+"_"+name)
+	up, down = base+".up.sql", base+".down.sql"
+	if err := os.WriteFile(up, []byte("-- "+name+" up\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(down, []byte("-- "+name+" down\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return up, down, nil
+}
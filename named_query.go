@@ -0,0 +1,45 @@
+package storm
+
+import (
+	"fmt"
+	"sync"
+)
+
+// namedQueries is the registry of vetted SQL, keyed by name
+type namedQueries struct {
+	mu      sync.RWMutex
+	queries map[string]string
+}
+
+// RegisterQuery registers sql under name, so it can be executed later with NamedQuery.
+// This give the team a central place to keep the hand written SQL of the application.
+// Registering the same name twice replace the previous query.
+// Example: db.RegisterQuery("activeUsers", "SELECT * FROM users WHERE status = $1")
+func (s *Storm) RegisterQuery(name, sql string) {
+	s.named.mu.Lock()
+	defer s.named.mu.Unlock()
+
+	if s.named.queries == nil {
+		s.named.queries = map[string]string{}
+	}
+	s.named.queries[name] = sql
+}
+
+// NamedQuery returns a query that execute the SQL registered under name with the given args.
+// Use Select or First on it to map the rows, for example:
+//
+//	var users []User
+//	err := db.NamedQuery("activeUsers", "active").Select(&users)
+//
+// If name is not registered, the error is returned when the query is executed.
+func (s *Storm) NamedQuery(name string, args ...interface{}) *Query {
+	s.named.mu.RLock()
+	sql, ok := s.named.queries[name]
+	s.named.mu.RUnlock()
+
+	q := &Query{storm: s, raw: sql, rawArgs: args}
+	if !ok {
+		q.err = fmt.Errorf("storm: named query %q is not registered", name)
+	}
+	return q
+}
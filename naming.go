@@ -0,0 +1,69 @@
+package storm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NamingStrategy decides the table name of a model and the column name of a field
+// when they are not given explicitly with `storm:"column:..."`.
+type NamingStrategy interface {
+	// TableName returns the table of the struct named structName, for example "User" -> "users".
+	TableName(structName string) string
+	// ColumnName returns the column of the field named fieldName, for example "Email" -> "email".
+	ColumnName(fieldName string) string
+}
+
+// DefaultNaming is the naming storm use when nothing else is configured:
+// the lowercase struct name plus "s" for tables (UserRole -> userroles) and the lowercase
+// field name for columns (CreatedAt -> createdat).
+// TablePrefix and TableSuffix are added around every table name, for example with
+// TablePrefix "app_" User is app_users, useful when sharing a database with other systems.
+type DefaultNaming struct {
+	TablePrefix string
+	TableSuffix string
+}
+
+func (n DefaultNaming) TableName(structName string) string {
+	return n.TablePrefix + strings.ToLower(structName+"s") + n.TableSuffix
+}
+
+func (DefaultNaming) ColumnName(fieldName string) string {
+	return strings.ToLower(fieldName)
+}
+
+// SnakeCaseNaming use snake_case names: UserRole -> user_roles, CreatedAt -> created_at.
+// TablePrefix and TableSuffix are added around every table name, like DefaultNaming.
+type SnakeCaseNaming struct {
+	TablePrefix string
+	TableSuffix string
+}
+
+func (n SnakeCaseNaming) TableName(structName string) string {
+	return n.TablePrefix + toSnakeCase(structName) + "s" + n.TableSuffix
+}
+
+func (SnakeCaseNaming) ColumnName(fieldName string) string {
+	return toSnakeCase(fieldName)
+}
+
+// toSnakeCase converts a Go identifier into snake_case, keeping initialisms together,
+// so "UserID" is "user_id" and "HTTPServer" is "http_server".
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			// start a new word when the previous rune is lowercase, or when we are at the last upper
+			// rune of an initialism followed by lowercase (the S of HTTPServer)
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
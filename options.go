@@ -0,0 +1,74 @@
+package storm
+
+import "time"
+
+// Option configures a Storm instance at construction, pass them to New.
+// Example: storm.New("postgres", dsn, storm.WithLogger(storm.NewStdLogger(nil)))
+type Option func(s *Storm)
+
+// ConnPool holds the settings of the database/sql connection pool, zero values keep the default.
+type ConnPool struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+// WithLogger sets the logger that receives every statement.
+func WithLogger(l Logger) Option {
+	return func(s *Storm) {
+		s.logger = l
+	}
+}
+
+// WithNamingStrategy sets how table and column names are derived from struct and field names.
+func WithNamingStrategy(n NamingStrategy) Option {
+	return func(s *Storm) {
+		s.schema = newSchemaCache(n)
+	}
+}
+
+// WithDialect overrides the dialect detected from the driver name,
+// for example when using a driver storm doesn't know by name.
+func WithDialect(d Dialect) Option {
+	return func(s *Storm) {
+		s.dialect = d
+	}
+}
+
+// WithConnPool configures the connection pool of the underlying *sql.DB.
+func WithConnPool(pool ConnPool) Option {
+	return func(s *Storm) {
+		s.pool = pool
+	}
+}
+
+// applyPool sets the settings of WithConnPool to the connection pool.
+func (s *Storm) applyPool() {
+	if s.pool.MaxOpenConns > 0 {
+		s.db.SetMaxOpenConns(s.pool.MaxOpenConns)
+	}
+	if s.pool.MaxIdleConns > 0 {
+		s.db.SetMaxIdleConns(s.pool.MaxIdleConns)
+	}
+	if s.pool.ConnMaxLifetime > 0 {
+		s.db.SetConnMaxLifetime(s.pool.ConnMaxLifetime)
+	}
+	if s.pool.ConnMaxIdleTime > 0 {
+		s.db.SetConnMaxIdleTime(s.pool.ConnMaxIdleTime)
+	}
+}
+
+// OnBegin registers fn to run at the start of every transaction, right after BEGIN.
+// If fn returns an error the transaction is rolled back and Begin returns the error.
+// It is the place to set transaction local settings, like the tenant of postgres row-level
+// security policies:
+//
+//	storm.OnBegin(func(tx *storm.Tx) error {
+//		return tx.SetLocal("app.current_tenant", tenantFrom(tx.Context()))
+//	})
+func OnBegin(fn func(tx *Tx) error) Option {
+	return func(s *Storm) {
+		s.beginHooks = append(s.beginHooks, fn)
+	}
+}
@@ -0,0 +1,167 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// orderTerm, is one expression of the ORDER BY clause with its direction and NULLS placement
+type orderTerm struct {
+	expr  rawExpr
+	dir   string // dir, ASC, DESC or empty for the default of the database
+	nulls string // nulls, FIRST, LAST or empty for the default of the database
+}
+
+// OrderByAsc orders the results by columns, ascending, after the orderings already added.
+// Example: .OrderByDesc("created_at").OrderByAsc("id") is `ORDER BY created_at DESC, id ASC`,
+// a deterministic order for stable pagination.
+func (q *Query) OrderByAsc(columns ...string) *Query {
+	return q.orderBy(columns, "ASC")
+}
+
+// OrderByDesc orders the results by columns, descending, after the orderings already added.
+func (q *Query) OrderByDesc(columns ...string) *Query {
+	return q.orderBy(columns, "DESC")
+}
+
+// NullsFirst puts the NULL values of the last ordering added before the other values.
+// Example: .OrderByDesc("deleted_at").NullsFirst() is `ORDER BY deleted_at DESC NULLS FIRST`.
+func (q *Query) NullsFirst() *Query {
+	return q.nulls("FIRST")
+}
+
+// NullsLast puts the NULL values of the last ordering added after the other values.
+// Example: .OrderByAsc("due_at").NullsLast() is `ORDER BY due_at ASC NULLS LAST`.
+// mysql has no NULLS LAST, it is emulated with `due_at IS NULL, due_at ASC`.
+func (q *Query) NullsLast() *Query {
+	return q.nulls("LAST")
+}
+
+// orderBy adds columns in direction dir to the ORDER BY clause, the columns must be
+// plain (optionally qualified) column names.
+func (q *Query) orderBy(columns []string, dir string) *Query {
+	for _, column := range columns {
+		if !isIdentifier(column) {
+			q.err = fmt.Errorf("storm: invalid column name %q in ORDER BY", column)
+			return q
+		}
+		q.orders = append(q.orders, orderTerm{expr: rawExpr{column, nil}, dir: dir})
+	}
+	return q
+}
+
+// pkOrder returns the ordering by the primary key of the model of the query, or of t when the
+// query was started from Table, so First and Paginate don't depend on the physical order of the
+// rows. queryCol are the selected columns. It returns nil when the query is ordered already,
+// grouped, selects an aggregate, or the model has no primary key.
+func (q *Query) pkOrder(t reflect.Type, queryCol []string) []orderTerm {
+	if len(q.orders) > 0 || len(q.groupBy) > 0 || len(q.having) > 0 {
+		return nil
+	}
+	// an aggregate returns one row, which has no primary key to order by
+	for _, col := range queryCol {
+		if aggregateCall.MatchString(col) {
+			return nil
+		}
+	}
+	for _, e := range q.extra {
+		if aggregateCall.MatchString(e.sql) {
+			return nil
+		}
+	}
+
+	if q.model != nil {
+		t = reflect.TypeOf(q.model).Elem()
+	}
+	info := q.storm.schema.parseType(t)
+	if info.PK == nil {
+		return nil
+	}
+	// qualified, the joined tables can have a column with the same name
+	return []orderTerm{{expr: rawExpr{q.qualified(info.PK.Column), nil}}}
+}
+
+// qualified returns column qualified with the table of the query, so it stays unambiguous when
+// other tables are joined. The table given to Table can be an expression, then the alias of its
+// first table is used, like u for "users u" or p for "posts p JOIN users u ON ...", and the column
+// is left bare when the expression doesn't start with a table, like a subquery.
+func (q *Query) qualified(column string) string {
+	words := strings.Fields(q.table)
+	if len(words) == 0 {
+		return column
+	}
+	name := strings.TrimSuffix(words[0], ",")
+	if !isIdentifier(name) {
+		return column
+	}
+	// "users, orders" has no alias
+	if len(words) > 1 && name == words[0] {
+		alias := words[1]
+		if strings.EqualFold(alias, "AS") && len(words) > 2 {
+			alias = words[2]
+		}
+		alias = strings.TrimSuffix(alias, ",")
+		if isIdentifier(alias) && !strings.Contains(alias, ".") && !tableKeywords[strings.ToUpper(alias)] {
+			name = alias
+		}
+	}
+	return name + "." + column
+}
+
+// tableKeywords are the words that can follow a table without being its alias.
+var tableKeywords = map[string]bool{
+	"AS": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "OUTER": true, "ON": true, "USING": true, "WHERE": true, "TABLESAMPLE": true,
+}
+
+// aggregateCall matches a call to an aggregate function, like COUNT(*) or sum(total).
+var aggregateCall = regexp.MustCompile(`(?i)\b(count|sum|avg|min|max|array_agg|string_agg|json_agg|jsonb_agg|json_object_agg|bool_and|bool_or|every|group_concat)\s*\(`)
+
+// nulls sets the NULLS placement of the last ordering.
+func (q *Query) nulls(placement string) *Query {
+	if len(q.orders) == 0 {
+		q.err = fmt.Errorf("storm: NULLS %s needs an ordering before it", placement)
+		return q
+	}
+	q.orders[len(q.orders)-1].nulls = placement
+	return q
+}
+
+// sql returns the SQL of the term for dialect, with the placeholders of its expression
+// numbered after offset, and its arguments.
+func (o orderTerm) sql(dialect string, offset int) (string, []interface{}) {
+	expr := shiftPlaceholders(o.expr.sql, offset)
+	order := expr
+	if o.dir != "" {
+		order += " " + o.dir
+	}
+	if o.nulls == "" {
+		return order, o.expr.args
+	}
+
+	if dialect == "mysql" {
+		// NULL sorts first in mysql, a boolean key before the expression moves them
+		key := expr + " IS NULL"
+		if o.nulls == "FIRST" {
+			key = expr + " IS NOT NULL"
+		}
+		args := append(append([]interface{}(nil), o.expr.args...), o.expr.args...)
+		return key + ", " + shiftPlaceholders(order, len(o.expr.args)), args
+	}
+	return order + " NULLS " + o.nulls, o.expr.args
+}
+
+// isIdentifier reports whether name is a column name, optionally qualified, like users.id.
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r == '.' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
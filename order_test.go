@@ -0,0 +1,46 @@
+package storm_test
+
+import (
+	"testing"
+
+	"github.com/pepega90/storm"
+)
+
+type user struct {
+	ID   int `storm:"pk"`
+	Name string
+}
+
+func TestFirstOrder(t *testing.T) {
+	db := newDryRun(t)
+	var u user
+	tests := []struct {
+		name  string
+		first func(tx *storm.Storm) error
+		sql   string
+	}{
+		{"model", func(tx *storm.Storm) error { return tx.From(&user{}).First(&u) },
+			"SELECT * FROM users ORDER BY users.id LIMIT $1"},
+		{"alias", func(tx *storm.Storm) error { return tx.Table("users u").First(&u) },
+			"SELECT * FROM users u ORDER BY u.id LIMIT $1"},
+		{"as alias", func(tx *storm.Storm) error { return tx.Table("users AS u").First(&u) },
+			"SELECT * FROM users AS u ORDER BY u.id LIMIT $1"},
+		{"join with aliases", func(tx *storm.Storm) error { return tx.Table("posts p JOIN users u ON u.id = p.user_id").First(&u) },
+			"SELECT * FROM posts p JOIN users u ON u.id = p.user_id ORDER BY p.id LIMIT $1"},
+		{"join", func(tx *storm.Storm) error { return tx.Table("posts JOIN users ON users.id = posts.user_id").First(&u) },
+			"SELECT * FROM posts JOIN users ON users.id = posts.user_id ORDER BY posts.id LIMIT $1"},
+		{"subquery", func(tx *storm.Storm) error { return tx.Table("(SELECT * FROM users) t").First(&u) },
+			"SELECT * FROM (SELECT * FROM users) t ORDER BY id LIMIT $1"},
+		{"ordered", func(tx *storm.Storm) error { return tx.From(&user{}).OrderByDesc("name").First(&u) },
+			"SELECT * FROM users ORDER BY name DESC LIMIT $1"},
+		{"grouped", func(tx *storm.Storm) error { return tx.From(&user{}).GroupBy("name").First(&u, "name") },
+			"SELECT name FROM users GROUP BY name LIMIT $1"},
+		{"aggregate", func(tx *storm.Storm) error { return tx.From(&user{}).First(&u, "COUNT(*) AS id") },
+			"SELECT COUNT(*) AS id FROM users LIMIT $1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkSQL(t, db, tt.first, storm.Statement{SQL: tt.sql, Args: []interface{}{1}})
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package storm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// outboxTable is where the outbox events are stored until they are delivered.
+const outboxTable = "storm_outbox"
+
+// OutboxEvent is a message written to the outbox in the same transaction as the business
+// write it describes, and delivered to the message broker afterwards, see Tx.Outbox.
+type OutboxEvent struct {
+	ID          int64      `storm:"pk;column:id"`
+	Topic       string     `storm:"column:topic;type:VARCHAR(255)"`
+	Payload     []byte     `storm:"column:payload"`
+	CreatedAt   time.Time  `storm:"column:created_at"`
+	DeliveredAt *time.Time `storm:"column:delivered_at"`
+	Attempts    int        `storm:"column:attempts"`   // Attempts, failed deliveries so far
+	LastError   string     `storm:"column:last_error"` // LastError, error of the last failed delivery
+}
+
+// CreateOutbox creates the storm_outbox table, if it doesn't exist yet.
+func (s *Storm) CreateOutbox() error {
+	// the columns are tagged explicitly, so only the table name of the schema is changed
+	info := *s.schema.parseType(reflect.TypeOf(OutboxEvent{}))
+	info.Table = outboxTable
+
+	ddl, err := s.createTableSQL(&info)
+	if err != nil {
+		return err
+	}
+	if _, err := s.exec(ddl); err != nil {
+		return fmt.Errorf("storm: create %s table: %v", outboxTable, err)
+	}
+	return nil
+}
+
+// Outbox writes event to the outbox in the transaction, so the event exists if and only if
+// the business write of the transaction is committed (transactional outbox pattern).
+// Only Topic and Payload of event are used:
+//
+//	err := db.Transaction(func(tx *storm.Tx) error {
+//		if err := tx.Insert(&order); err != nil {
+//			return err
+//		}
+//		payload, _ := json.Marshal(order)
+//		return tx.Outbox(storm.OutboxEvent{Topic: "order.created", Payload: payload})
+//	})
+//
+// The events are delivered by DeliverOutbox or PollOutbox.
+func (t *Tx) Outbox(event OutboxEvent) error {
+	if event.Topic == "" {
+		return fmt.Errorf("storm: outbox event needs a topic")
+	}
+	q := fmt.Sprintf("INSERT INTO %s (topic, payload, created_at, attempts, last_error) VALUES ($1, $2, $3, 0, '')", t.tableName(outboxTable))
+	_, err := t.exec(q, event.Topic, event.Payload, time.Now())
+	return err
+}
+
+// DeliverOutbox delivers up to limit pending events, oldest first, calling deliver for each one,
+// and returns how many were delivered. A delivered event is marked with its delivery time,
+// an event deliver fails for keeps its error and is retried on the next call.
+//
+// The events are locked while they are delivered (FOR UPDATE SKIP LOCKED on postgres and mysql),
+// so several pollers can run side by side. Delivery is at least once: an event whose mark
+// fails to commit is delivered again, so consumers must handle duplicates.
+func (s *Storm) DeliverOutbox(limit int, deliver func(ctx context.Context, event OutboxEvent) error) (int, error) {
+	if limit <= 0 {
+		limit = 100
+	}
+
+	delivered := 0
+	err := s.Transaction(func(tx *Tx) error {
+		table := tx.tableName(outboxTable)
+
+		q := fmt.Sprintf("SELECT id, topic, payload, created_at, attempts FROM %s WHERE delivered_at IS NULL ORDER BY id LIMIT $1", table)
+		if tx.dialect.Name() != "sqlite3" {
+			q += " FOR UPDATE SKIP LOCKED"
+		}
+
+		var events []OutboxEvent
+		err := tx.queryRows(q, []interface{}{limit}, func(rows *sql.Rows) error {
+			for rows.Next() {
+				var e OutboxEvent
+				if err := rows.Scan(&e.ID, &e.Topic, &e.Payload, &e.CreatedAt, &e.Attempts); err != nil {
+					return err
+				}
+				events = append(events, e)
+			}
+			return rows.Err()
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, e := range events {
+			if err := deliver(tx.ctx, e); err != nil {
+				q := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = $1 WHERE id = $2", table)
+				if _, err := tx.exec(q, err.Error(), e.ID); err != nil {
+					return err
+				}
+				continue
+			}
+
+			q := fmt.Sprintf("UPDATE %s SET delivered_at = $1 WHERE id = $2", table)
+			if _, err := tx.exec(q, time.Now(), e.ID); err != nil {
+				return err
+			}
+			delivered++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storm: deliver outbox: %w", err)
+	}
+	return delivered, nil
+}
+
+// OutboxPoller configures PollOutbox.
+type OutboxPoller struct {
+	Interval  time.Duration                                      // Interval, between two polls when the outbox is empty, default 1 second
+	BatchSize int                                                // BatchSize, events delivered per transaction, default 100
+	Deliver   func(ctx context.Context, event OutboxEvent) error // Deliver, publish the event to the broker
+	OnError   func(err error)                                    // OnError, optional, receive the errors of the database while polling
+}
+
+// PollOutbox delivers the outbox events with poller.Deliver until ctx is done, then it
+// returns ctx.Err(). Full batches are followed by the next one right away, so a backlog is
+// drained quickly. Errors of the database don't stop the poller, they are given to poller.OnError.
+//
+//	go db.PollOutbox(ctx, storm.OutboxPoller{
+//		Deliver: func(ctx context.Context, e storm.OutboxEvent) error {
+//			return producer.Publish(ctx, e.Topic, e.Payload)
+//		},
+//	})
+func (s *Storm) PollOutbox(ctx context.Context, poller OutboxPoller) error {
+	if poller.Deliver == nil {
+		return fmt.Errorf("storm: outbox poller needs a Deliver function")
+	}
+	if poller.Interval <= 0 {
+		poller.Interval = time.Second
+	}
+	if poller.BatchSize <= 0 {
+		poller.BatchSize = 100
+	}
+
+	session := s.WithContext(ctx)
+	for {
+		delivered, err := session.DeliverOutbox(poller.BatchSize, poller.Deliver)
+		if err != nil && poller.OnError != nil && ctx.Err() == nil {
+			poller.OnError(err)
+		}
+
+		wait := poller.Interval
+		if err == nil && delivered == poller.BatchSize {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Page is one page of results returned by PaginatePage, together with the totals
+// needed to render a pager. It can be encoded to JSON directly.
+type Page struct {
+	Items      interface{} `json:"items"` // Items, slice of the model, for example []User
+	Total      int         `json:"total"`
+	TotalPages int         `json:"total_pages"`
+	Page       int         `json:"page"`
+	PageSize   int         `json:"page_size"`
+	HasNext    bool        `json:"has_next"`
+}
+
+// PaginatePage executes the query with pagination and returns the page of results.
+// Items is a slice of the query model, for example:
+//
+//	page, err := db.From(&User{}).PaginatePage(2, 10)
+//	users := page.Items.([]User)
+//
+// The query must be started from a model (From or Model), since we need to know
+// what type of slice to create. Use QueryOf for a typed result instead of type assertion.
+func (q *Query) PaginatePage(page, pageSize int, queryCol ...string) (*Page, error) {
+	if q.model == nil {
+		return nil, fmt.Errorf("storm: PaginatePage needs a query started from a model")
+	}
+
+	info, err := q.storm.schema.parseModel(q.model)
+	if err != nil {
+		return nil, err
+	}
+
+	// items, is pointer to empty slice of the model, so *[]User
+	items := reflect.New(reflect.SliceOf(info.Type))
+	result, err := q.paginate(items.Interface(), page, pageSize, queryCol...)
+	if err != nil {
+		return nil, err
+	}
+	result.Items = items.Elem().Interface()
+	return result, nil
+}
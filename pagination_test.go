@@ -0,0 +1,48 @@
+package storm_test
+
+import (
+	"testing"
+
+	"github.com/pepega90/storm"
+)
+
+// visit has no primary key, its pages are ordered by id
+type visit struct {
+	Path string
+}
+
+func TestPaginateOrder(t *testing.T) {
+	db := newDryRun(t)
+	var users []user
+	var visits []visit
+	var total, pages int
+
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.From(&user{}).Paginate(&users, 2, 10, &total, &pages)
+	},
+		storm.Statement{SQL: "SELECT COUNT(*) FROM users"},
+		storm.Statement{SQL: "SELECT * FROM users ORDER BY users.id LIMIT $1 OFFSET $2", Args: []interface{}{10, 10}},
+	)
+
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.Table("users u").Paginate(&users, 2, 10, &total, &pages)
+	},
+		storm.Statement{SQL: "SELECT COUNT(*) FROM users u"},
+		storm.Statement{SQL: "SELECT * FROM users u ORDER BY u.id LIMIT $1 OFFSET $2", Args: []interface{}{10, 10}},
+	)
+
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.Table("visits v JOIN users u ON u.id = v.user_id").Paginate(&visits, 1, 10, &total, &pages)
+	},
+		storm.Statement{SQL: "SELECT COUNT(*) FROM visits v JOIN users u ON u.id = v.user_id"},
+		storm.Statement{SQL: "SELECT * FROM visits v JOIN users u ON u.id = v.user_id ORDER BY v.id LIMIT $1", Args: []interface{}{10}},
+	)
+
+	// a grouped query keeps its own ordering, it has no id
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		return tx.From(&user{}).GroupBy("name").Paginate(&users, 1, 10, &total, &pages, "name")
+	},
+		storm.Statement{SQL: "SELECT COUNT(*) OVER () FROM users GROUP BY name LIMIT $1", Args: []interface{}{1}},
+		storm.Statement{SQL: "SELECT name FROM users GROUP BY name LIMIT $1", Args: []interface{}{10}},
+	)
+}
@@ -0,0 +1,132 @@
+package storm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// partition, is the partitioning of a model, from the `storm:"partition:range"` tag
+type partition struct {
+	strategy string // RANGE or LIST
+	field    *SchemaField
+}
+
+// partitionOf returns the partitioning of the model described by info, nil if it is not partitioned.
+func partitionOf(info *Schema) (*partition, error) {
+	var result *partition
+	for _, field := range info.Fields {
+		strategy, ok := field.Tag["partition"]
+		if !ok {
+			continue
+		}
+		if result != nil {
+			return nil, fmt.Errorf("storm: %s has more than one partition field", info.Type.Name())
+		}
+
+		strategy = strings.ToUpper(strategy)
+		if strategy != "RANGE" && strategy != "LIST" {
+			return nil, fmt.Errorf("storm: unknown partition strategy %q of %s.%s, use range or list", field.Tag["partition"], info.Type.Name(), field.Name)
+		}
+		result = &partition{strategy: strategy, field: field}
+	}
+	return result, nil
+}
+
+// partitionedSchema returns the metadata of model, which must be a partitioned model with strategy.
+func (s *Storm) partitionedSchema(model interface{}, strategy string) (*Schema, error) {
+	info, err := s.schema.parseModel(model)
+	if err != nil {
+		return nil, err
+	}
+	p, err := partitionOf(info)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil || p.strategy != strategy {
+		return nil, fmt.Errorf("storm: %s is not partitioned by %s", info.Type.Name(), strings.ToLower(strategy))
+	}
+	return info, nil
+}
+
+// CreateRangePartition creates the partition name of the range partitioned model,
+// holding the rows whose partition column is in [from, to).
+// DDL has no placeholders, so from and to are written as SQL literals.
+func (s *Storm) CreateRangePartition(model interface{}, name string, from, to interface{}) error {
+	info, err := s.partitionedSchema(model, "RANGE")
+	if err != nil {
+		return err
+	}
+
+	lower, err := sqlLiteral(from)
+	if err != nil {
+		return err
+	}
+	upper, err := sqlLiteral(to)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES FROM (%s) TO (%s)", s.tableName(name), s.tableName(info.Table), lower, upper))
+	return err
+}
+
+// CreateMonthlyPartition creates the partition of the month of t for the range partitioned model,
+// named after the table and the month, like orders_y2025m03. Call it ahead of time from a
+// scheduled job, so the partition exists before the rows of the month arrive.
+func (s *Storm) CreateMonthlyPartition(model interface{}, t time.Time) error {
+	info, err := s.partitionedSchema(model, "RANGE")
+	if err != nil {
+		return err
+	}
+
+	from := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	name := fmt.Sprintf("%s_y%04dm%02d", info.Table, from.Year(), from.Month())
+	return s.CreateRangePartition(model, name, from, from.AddDate(0, 1, 0))
+}
+
+// CreateListPartition creates the partition name of the list partitioned model,
+// holding the rows whose partition column is one of values, like the tenants of a shard.
+func (s *Storm) CreateListPartition(model interface{}, name string, values ...interface{}) error {
+	info, err := s.partitionedSchema(model, "LIST")
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("storm: list partition %s needs at least one value", name)
+	}
+
+	literals := make([]string, len(values))
+	for i, v := range values {
+		if literals[i], err = sqlLiteral(v); err != nil {
+			return err
+		}
+	}
+
+	_, err = s.exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s PARTITION OF %s FOR VALUES IN (%s)", s.tableName(name), s.tableName(info.Table), strings.Join(literals, ", ")))
+	return err
+}
+
+// DropPartition drops the partition name together with its rows, for example to expire old months.
+func (s *Storm) DropPartition(name string) error {
+	_, err := s.exec(fmt.Sprintf("DROP TABLE IF EXISTS %s", s.tableName(name)))
+	return err
+}
+
+// sqlLiteral writes v as a SQL literal, for statements that can't use placeholders.
+func sqlLiteral(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return quoteLiteral(v), nil
+	case time.Time:
+		return quoteLiteral(v.Format("2006-01-02 15:04:05.999999")), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+	return "", fmt.Errorf("storm: cannot write %T as a SQL literal", v)
+}
@@ -0,0 +1,234 @@
+package storm
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxPlanQueries is how many different statements the analyzer counts between two runs,
+// the statements seen after that are not counted until the next run.
+const maxPlanQueries = 1000
+
+// PlanAnalyzer configures AnalyzePlans, zero values use the default.
+type PlanAnalyzer struct {
+	Interval    time.Duration               // Interval, between two runs, default 1 minute
+	Top         int                         // Top, most frequent statements explained every run, default 10
+	SeqScanRows float64                     // SeqScanRows, warn on a sequential scan estimated at more rows, default 10000
+	MaxRows     float64                     // MaxRows, warn when the statement is estimated to return more rows, default 100000
+	OnWarning   func(w PlanWarning)         // OnWarning, receive the warnings, default log them with the log package
+	OnError     func(sql string, err error) // OnError, optional, receive the errors of EXPLAIN
+}
+
+// PlanWarning is a problem AnalyzePlans found in the plan of a frequent statement.
+type PlanWarning struct {
+	SQL     string  // SQL, the statement
+	Count   int     // Count, executions of the statement during the last interval
+	Kind    string  // Kind, "seq_scan" or "rows"
+	Table   string  // Table, the table scanned sequentially, empty for rows
+	Rows    float64 // Rows, the rows estimated by the planner
+	Message string  // Message, the warning in words
+}
+
+// planStats counts the SELECT statements for AnalyzePlans, it is shared by every session.
+type planStats struct {
+	enabled atomic.Bool
+
+	mu      sync.Mutex
+	queries map[string]*planQuery
+}
+
+// planQuery, a statement seen since the last run with the arguments of its last execution
+type planQuery struct {
+	count int
+	args  []interface{}
+}
+
+// AnalyzePlans EXPLAINs the most frequent SELECT statements storm executes every analyzer.Interval
+// until ctx is done, then it returns ctx.Err(). A sequential scan or a row estimate above the
+// thresholds is reported to OnWarning, once per statement, so a plan that regressed (a dropped
+// index, a table that grew) shows up in the logs before it shows up in the latency:
+//
+//	go db.AnalyzePlans(ctx, storm.PlanAnalyzer{
+//		SeqScanRows: 50000,
+//		OnWarning:   func(w storm.PlanWarning) { logger.Warn(w.Message, "sql", w.SQL) },
+//	})
+//
+// The statements are explained with the arguments of their last execution, without ANALYZE,
+// so they are planned but never run. It is only supported on postgres.
+func (s *Storm) AnalyzePlans(ctx context.Context, analyzer PlanAnalyzer) error {
+	if s.dialect.Name() != "postgres" {
+		return fmt.Errorf("storm: AnalyzePlans is only supported on postgres, not %s", s.dialect.Name())
+	}
+	if analyzer.Interval <= 0 {
+		analyzer.Interval = time.Minute
+	}
+	if analyzer.Top <= 0 {
+		analyzer.Top = 10
+	}
+	if analyzer.SeqScanRows <= 0 {
+		analyzer.SeqScanRows = 10000
+	}
+	if analyzer.MaxRows <= 0 {
+		analyzer.MaxRows = 100000
+	}
+	if analyzer.OnWarning == nil {
+		analyzer.OnWarning = func(w PlanWarning) {
+			log.Printf("storm: plan warning: %s, executed %d times: %s", w.Message, w.Count, w.SQL)
+		}
+	}
+
+	s.plans.enabled.Store(true)
+	defer s.plans.enabled.Store(false)
+
+	// EXPLAIN is never counted, so the analyzer doesn't analyze itself
+	session := s.Session(&SessionConfig{Context: ctx})
+	warned := map[string]bool{}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(analyzer.Interval):
+		}
+
+		for _, q := range s.plans.top(analyzer.Top) {
+			warnings, err := session.explain(q.sql, q.args, analyzer)
+			if err != nil {
+				if analyzer.OnError != nil && ctx.Err() == nil {
+					analyzer.OnError(q.sql, err)
+				}
+				continue
+			}
+			for _, w := range warnings {
+				key := w.Kind + " " + w.Table + " " + w.SQL
+				if warned[key] {
+					continue
+				}
+				warned[key] = true
+				w.Count = q.count
+				analyzer.OnWarning(w)
+			}
+		}
+	}
+}
+
+// record counts e, a statement that was executed, when AnalyzePlans runs.
+func (p *planStats) record(e QueryEvent) {
+	if !p.enabled.Load() || e.DryRun || e.Err != nil {
+		return
+	}
+	if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(e.SQL)), "SELECT") {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.queries == nil {
+		p.queries = map[string]*planQuery{}
+	}
+	q, ok := p.queries[e.SQL]
+	if !ok {
+		if len(p.queries) >= maxPlanQueries {
+			return
+		}
+		q = &planQuery{}
+		p.queries[e.SQL] = q
+	}
+	q.count++
+	q.args = e.Args
+}
+
+// frequentQuery, a statement to explain with how often it ran
+type frequentQuery struct {
+	sql   string
+	count int
+	args  []interface{}
+}
+
+// top returns the n statements executed the most since the last call, and starts counting again.
+func (p *planStats) top(n int) []frequentQuery {
+	p.mu.Lock()
+	queries := p.queries
+	p.queries = nil
+	p.mu.Unlock()
+
+	result := make([]frequentQuery, 0, len(queries))
+	for sql, q := range queries {
+		result = append(result, frequentQuery{sql: sql, count: q.count, args: q.args})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].count > result[j].count
+	})
+	if len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// planNode, is a node of the JSON output of postgres EXPLAIN
+type planNode struct {
+	NodeType     string     `json:"Node Type"`
+	RelationName string     `json:"Relation Name"`
+	PlanRows     float64    `json:"Plan Rows"`
+	Plans        []planNode `json:"Plans"`
+}
+
+// explain returns the warnings of the plan of query with args.
+func (s *Storm) explain(query string, args []interface{}, analyzer PlanAnalyzer) ([]PlanWarning, error) {
+	var doc []byte
+	err := s.queryRows("EXPLAIN (FORMAT JSON) "+query, args, func(rows *sql.Rows) error {
+		if rows.Next() {
+			return rows.Scan(&doc)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []struct {
+		Plan planNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(doc, &plans); err != nil {
+		return nil, fmt.Errorf("storm: read plan: %v", err)
+	}
+
+	var warnings []PlanWarning
+	for _, p := range plans {
+		if p.Plan.PlanRows > analyzer.MaxRows {
+			warnings = append(warnings, PlanWarning{
+				SQL:     query,
+				Kind:    "rows",
+				Rows:    p.Plan.PlanRows,
+				Message: fmt.Sprintf("estimated to return %.0f rows", p.Plan.PlanRows),
+			})
+		}
+		walkPlan(p.Plan, func(node planNode) {
+			if node.NodeType == "Seq Scan" && node.PlanRows > analyzer.SeqScanRows {
+				warnings = append(warnings, PlanWarning{
+					SQL:     query,
+					Kind:    "seq_scan",
+					Table:   node.RelationName,
+					Rows:    node.PlanRows,
+					Message: fmt.Sprintf("sequential scan of %s estimated at %.0f rows", node.RelationName, node.PlanRows),
+				})
+			}
+		})
+	}
+	return warnings, nil
+}
+
+// walkPlan calls fn with node and every node below it.
+func walkPlan(node planNode, fn func(node planNode)) {
+	fn(node)
+	for _, child := range node.Plans {
+		walkPlan(child, fn)
+	}
+}
@@ -0,0 +1,112 @@
+package storm
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Plugin extends storm with behavior for every model, like auditing or caching.
+// Initialize is called once by Use, it usually registers callbacks with RegisterCallback.
+type Plugin interface {
+	Name() string
+	Initialize(s *Storm) error
+}
+
+// Callback runs around the operations of storm on every model, like a model hook but registered
+// once for all the models. model is the model given to the operation, for example the *User of Insert.
+// An error returned by a Before callback stops the operation.
+type Callback func(s *Storm, model interface{}) error
+
+// writeOp, the state of a single Update or Delete shared by its hooks and callbacks, it is
+// dropped with the write whatever its outcome
+type writeOp struct {
+	affected int64                  // affected, rows changed by the statement, -1 before it ran
+	values   map[string]interface{} // values, kept by SetWriteValue
+}
+
+// startWrite returns a copy of the session s for an Update or Delete, with its own writeOp.
+func (s *Storm) startWrite() *Storm {
+	write := *s
+	write.write = &writeOp{affected: -1}
+	return &write
+}
+
+// RowsAffected returns the number of rows changed by the Update or Delete whose After hooks and
+// callbacks are running, so they can skip a write that matched no row. It is -1 anywhere else.
+func (s *Storm) RowsAffected() int64 {
+	if s.write == nil {
+		return -1
+	}
+	return s.write.affected
+}
+
+// SetWriteValue keeps value under key for the rest of the Update or Delete whose Before hooks and
+// callbacks are running, so its After ones read it back with WriteValue, like the old values of
+// the row for an audit log. The values are dropped with the write, whether it succeeds or not.
+// It does nothing outside an Update or Delete.
+func (s *Storm) SetWriteValue(key string, value interface{}) {
+	if s.write == nil {
+		return
+	}
+	if s.write.values == nil {
+		s.write.values = map[string]interface{}{}
+	}
+	s.write.values[key] = value
+}
+
+// WriteValue returns the value kept under key by SetWriteValue during the current Update or Delete.
+func (s *Storm) WriteValue(key string) (interface{}, bool) {
+	if s.write == nil {
+		return nil, false
+	}
+	value, ok := s.write.values[key]
+	return value, ok
+}
+
+// callbackRegistry, keep the callbacks of every hook kind in the order they were registered
+type callbackRegistry struct {
+	mu        sync.RWMutex
+	callbacks map[HookKind][]Callback
+	plugins   map[string]Plugin
+}
+
+// Use initializes plugins on s, a plugin name can only be used once.
+// The plugins apply to every session of s.
+func (s *Storm) Use(plugins ...Plugin) error {
+	for _, p := range plugins {
+		s.callbacks.mu.Lock()
+		if s.callbacks.plugins == nil {
+			s.callbacks.plugins = map[string]Plugin{}
+		}
+		_, used := s.callbacks.plugins[p.Name()]
+		s.callbacks.plugins[p.Name()] = p
+		s.callbacks.mu.Unlock()
+
+		if used {
+			return fmt.Errorf("storm: plugin %s is already used", p.Name())
+		}
+		if err := p.Initialize(s); err != nil {
+			return fmt.Errorf("storm: initialize plugin %s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+// RegisterCallback registers fn to run at kind for every model, after the model hook.
+// Unlike model hooks, callbacks still run in a session with SkipHooks.
+func (s *Storm) RegisterCallback(kind HookKind, fn Callback) {
+	s.callbacks.mu.Lock()
+	defer s.callbacks.mu.Unlock()
+
+	if s.callbacks.callbacks == nil {
+		s.callbacks.callbacks = map[HookKind][]Callback{}
+	}
+	s.callbacks.callbacks[kind] = append(s.callbacks.callbacks[kind], fn)
+}
+
+// of returns the callbacks registered for kind.
+func (r *callbackRegistry) of(kind HookKind) []Callback {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.callbacks[kind]
+}
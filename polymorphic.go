@@ -0,0 +1,164 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// typeRegistry, keep the concrete types of the interfaces registered with RegisterTypes, and the
+// subtypes of the models registered with RegisterSubtypes
+type typeRegistry struct {
+	mu         sync.RWMutex
+	interfaces map[reflect.Type]*polymorphic
+	bases      map[reflect.Type]*polymorphic
+}
+
+// polymorphic, the concrete types of an interface, by the value of their discriminator column
+type polymorphic struct {
+	column string
+	types  map[string]concreteType
+}
+
+// concreteType, a struct type that implements the interface, itself or through its pointer (ptr true)
+type concreteType struct {
+	typ reflect.Type
+	ptr bool
+}
+
+// RegisterTypes registers the struct types implementing the interface iface, given as a nil
+// pointer to it, by the value of the discriminator column, so Select can fill a slice of the
+// interface with the right type for every row:
+//
+//	db.RegisterTypes((*Notifier)(nil), "kind", map[string]interface{}{
+//		"email": &EmailNotifier{}, // *EmailNotifier implements Notifier
+//		"sms":   SMSNotifier{},    // SMSNotifier implements Notifier
+//	})
+//
+//	var notifiers []Notifier
+//	err := db.Table("notifiers").Select(&notifiers)
+//
+// Every row is mapped into a new value of the type of its discriminator, a pointer when the
+// registered value is a pointer, the columns the type has no field for are ignored.
+// Registering an interface again replaces its types.
+func (s *Storm) RegisterTypes(iface interface{}, column string, types map[string]interface{}) error {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("storm: RegisterTypes needs a nil pointer to an interface, like (*Notifier)(nil), got %s", describe(iface))
+	}
+	t = t.Elem()
+
+	poly := &polymorphic{column: column, types: make(map[string]concreteType, len(types))}
+	for value, model := range types {
+		mt := reflect.TypeOf(model)
+		concrete := concreteType{typ: mt}
+		if mt != nil && mt.Kind() == reflect.Ptr {
+			concrete = concreteType{typ: mt.Elem(), ptr: true}
+		}
+		if concrete.typ == nil || concrete.typ.Kind() != reflect.Struct {
+			return fmt.Errorf("storm: RegisterTypes type of %s = %q must be a struct or a pointer to a struct, got %s", column, value, describe(model))
+		}
+		if !mt.Implements(t) {
+			return fmt.Errorf("storm: RegisterTypes type %s of %s = %q doesn't implement %s", mt, column, value, t)
+		}
+		poly.types[value] = concrete
+	}
+
+	s.types.mu.Lock()
+	defer s.types.mu.Unlock()
+	if s.types.interfaces == nil {
+		s.types.interfaces = map[reflect.Type]*polymorphic{}
+	}
+	s.types.interfaces[t] = poly
+	return nil
+}
+
+// polymorphicOf returns the registered types of dest when it is a pointer to a slice of an
+// interface registered with RegisterTypes, or of any interface when model, the model of the
+// query, has subtypes registered with RegisterSubtypes. It returns nil otherwise.
+func (s *Storm) polymorphicOf(dest, model interface{}) *polymorphic {
+	t := reflect.TypeOf(dest)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Slice || t.Elem().Elem().Kind() != reflect.Interface {
+		return nil
+	}
+	s.types.mu.RLock()
+	defer s.types.mu.RUnlock()
+	if poly, ok := s.types.interfaces[t.Elem().Elem()]; ok {
+		return poly
+	}
+	if model != nil {
+		return s.types.bases[reflect.TypeOf(model).Elem()]
+	}
+	return nil
+}
+
+// selectPolymorphic is Select for dest, a pointer to a slice of an interface with the types poly.
+func (q *Query) selectPolymorphic(dest interface{}, poly *polymorphic, queryCol []string) error {
+	if reflect.ValueOf(dest).IsNil() {
+		return fmt.Errorf("storm: dest must be a non-nil pointer to a slice, got %s", describe(dest))
+	}
+
+	query, args, err := q.buildSelect(queryCol, q.limit)
+	if err != nil {
+		return err
+	}
+
+	return q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		cols, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+		discriminator := -1
+		for i, col := range cols {
+			if col == poly.column {
+				discriminator = i
+			}
+		}
+		if discriminator < 0 {
+			return fmt.Errorf("storm: the result has no column %s to choose the type of the rows", poly.column)
+		}
+
+		sliceVal := reflect.ValueOf(dest).Elem()
+		// the column to field mapping of every type is only built once
+		fieldsByType := map[reflect.Type]map[string]*SchemaField{}
+		for rows.Next() {
+			vals, err := scanValues(rows, len(cols))
+			if err != nil {
+				return err
+			}
+
+			value := vals[discriminator]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+			concrete, ok := poly.types[fmt.Sprint(value)]
+			if !ok {
+				return fmt.Errorf("storm: no type is registered for %s = %v", poly.column, value)
+			}
+
+			fields, ok := fieldsByType[concrete.typ]
+			if !ok {
+				fields = q.fieldsFor(concrete.typ, cols)
+				fieldsByType[concrete.typ] = fields
+			}
+
+			// the columns of the other types are expected, so the row is never checked as all columns
+			newStruct := reflect.New(concrete.typ).Elem()
+			if err := q.mapRow(newStruct, cols, vals, fields, false); err != nil {
+				return err
+			}
+			if err := q.storm.callHook(HookAfterFind, newStruct.Addr().Interface()); err != nil {
+				return err
+			}
+			if concrete.ptr {
+				newStruct = newStruct.Addr()
+			}
+			if !newStruct.Type().Implements(sliceVal.Type().Elem()) {
+				return fmt.Errorf("storm: %s of %s = %v doesn't implement %s", newStruct.Type(), poly.column, value, sliceVal.Type().Elem())
+			}
+			sliceVal.Set(reflect.Append(sliceVal, newStruct))
+		}
+		return rows.Err()
+	})
+}
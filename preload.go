@@ -0,0 +1,226 @@
+package storm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// defaultPreloadWorkers is how many associations are preloaded at the same time without
+// WithPreloadConcurrency.
+const defaultPreloadWorkers = 4
+
+// preloadChunkSize is the most keys an association query looks up at once, the keys of more
+// models are split into several queries, below the 999 parameters of older sqlite and far from
+// the 65535 of postgres, leaving room for the ones of the scopes.
+const preloadChunkSize = 900
+
+// WithPreloadConcurrency sets how many associations of a query Preload loads at the same time,
+// every one on its own connection, default 4. 1 loads them one after the other.
+func WithPreloadConcurrency(n int) Option {
+	return func(s *Storm) {
+		s.preloadWorkers = n
+	}
+}
+
+// Preload fills the associations named fields (Go field names) of the models found by Select,
+// First and Paginate, with one query per association, whatever the number of models. An
+// association is a field tagged with hasmany or belongsto, it is not a column:
+//
+//	type User struct {
+//		ID    int    `storm:"pk"`
+//		Posts []Post `storm:"hasmany:author_id"` // posts.author_id references users.id
+//	}
+//
+//	type Post struct {
+//		ID       int   `storm:"pk"`
+//		AuthorID int
+//		Author   *User `storm:"belongsto:author_id"` // posts.author_id references users.id
+//	}
+//
+//	err := db.From(&User{}).Preload("Posts").Select(&users)
+//	// SELECT * FROM users
+//	// SELECT * FROM posts WHERE author_id IN ($1,$2,...)
+//
+// The keys are looked up 900 at a time, one query per chunk, so any number of models fit in
+// the parameters a statement allows.
+// The queries of several associations run concurrently, up to WithPreloadConcurrency at the
+// same time, so an endpoint loading many relations waits for the slowest one, not for their sum.
+// No new query starts once the context of the session is done. In a transaction they run one
+// after the other, since a transaction has a single connection. The associated rows go through
+// the global scopes and the soft deletes like any query.
+func (q *Query) Preload(fields ...string) *Query {
+	q.preload = append(q.preload, fields...)
+	return q
+}
+
+// preloadInto fills the associations named by Preload of models, addressable struct values of
+// the model of the query.
+func (q *Query) preloadInto(models []reflect.Value) error {
+	if len(q.preload) == 0 || len(models) == 0 {
+		return nil
+	}
+	info := q.storm.schema.parseType(models[0].Type())
+	relations := make([]*Relation, len(q.preload))
+	for i, name := range q.preload {
+		if relations[i] = info.relation(name); relations[i] == nil {
+			return fmt.Errorf("storm: %s has no association %s, tag the field with hasmany or belongsto", info.Type.Name(), name)
+		}
+	}
+
+	workers := q.storm.preloadWorkers
+	if workers <= 0 {
+		workers = defaultPreloadWorkers
+	}
+	if q.storm.inTx() {
+		workers = 1
+	}
+
+	// every association writes its own field of the models, so they never write the same memory
+	errs := make([]error, len(relations))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, rel := range relations {
+		// no new query once the context is done, the running ones are canceled by it
+		if errs[i] = q.storm.ctx.Err(); errs[i] != nil {
+			break
+		}
+		select {
+		case <-q.storm.ctx.Done():
+			errs[i] = q.storm.ctx.Err()
+		case sem <- struct{}{}:
+		}
+		if errs[i] != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, rel *Relation) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = q.storm.preloadRelation(info, rel, models)
+		}(i, rel)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// preloadRelation fills rel of models, models of info, with a query per preloadChunkSize keys.
+func (s *Storm) preloadRelation(info *Schema, rel *Relation, models []reflect.Value) error {
+	target := rel.Type
+	if rel.Kind == "hasmany" {
+		if target.Kind() != reflect.Slice {
+			return fmt.Errorf("storm: the hasmany field %s.%s must be a slice, not %s", info.Type.Name(), rel.Name, rel.Type)
+		}
+		target = target.Elem()
+	}
+	ptr := target.Kind() == reflect.Ptr
+	if ptr {
+		target = target.Elem()
+	}
+	if target.Kind() != reflect.Struct {
+		return fmt.Errorf("storm: the %s field %s.%s must hold a model, not %s", rel.Kind, info.Type.Name(), rel.Name, rel.Type)
+	}
+	other := s.schema.parseType(target)
+
+	// the column we look the rows up by, and the field of the other model and of ours matched by it
+	var column string
+	var theirs, ours *SchemaField
+	if rel.Kind == "hasmany" {
+		if info.PK == nil {
+			return fmt.Errorf("storm: %s has no primary key to preload %s", info.Type.Name(), rel.Name)
+		}
+		if theirs = other.field(rel.ForeignKey); theirs == nil {
+			return fmt.Errorf("storm: %s has no column %s to preload %s.%s", other.Type.Name(), rel.ForeignKey, info.Type.Name(), rel.Name)
+		}
+		column, ours = rel.ForeignKey, info.PK
+	} else {
+		if ours = info.field(rel.ForeignKey); ours == nil {
+			return fmt.Errorf("storm: %s has no column %s to preload %s", info.Type.Name(), rel.ForeignKey, rel.Name)
+		}
+		if other.PK == nil {
+			return fmt.Errorf("storm: %s has no primary key to preload %s.%s", other.Type.Name(), info.Type.Name(), rel.Name)
+		}
+		column, theirs = other.PK.Column, other.PK
+	}
+
+	keys := []interface{}{}
+	seen := map[string]bool{}
+	for _, model := range models {
+		value := ours.Value(model)
+		key, ok := relationKey(value)
+		if ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, value.Interface())
+		}
+	}
+
+	rows := reflect.New(reflect.SliceOf(target))
+	for start := 0; start < len(keys); start += preloadChunkSize {
+		end := start + preloadChunkSize
+		if end > len(keys) {
+			end = len(keys)
+		}
+		chunk := reflect.New(reflect.SliceOf(target))
+		if err := s.From(reflect.New(target).Interface()).WhereExpr(In{column: keys[start:end]}).Select(chunk.Interface()); err != nil {
+			return err
+		}
+		rows.Elem().Set(reflect.AppendSlice(rows.Elem(), chunk.Elem()))
+	}
+
+	// the rows of the other model by the key they are matched with
+	byKey := map[string][]reflect.Value{}
+	for i := 0; i < rows.Elem().Len(); i++ {
+		row := rows.Elem().Index(i)
+		if key, ok := relationKey(theirs.Value(row)); ok {
+			if ptr {
+				row = row.Addr()
+			}
+			byKey[key] = append(byKey[key], row)
+		}
+	}
+
+	for _, model := range models {
+		key, ok := relationKey(ours.Value(model))
+		field := rel.Alloc(model)
+		switch {
+		case rel.Kind == "hasmany":
+			// an empty slice rather than nil, the model has been looked up and has none
+			list := reflect.MakeSlice(rel.Type, 0, len(byKey[key]))
+			if ok {
+				list = reflect.Append(list, byKey[key]...)
+			}
+			field.Set(list)
+		case ok && len(byKey[key]) > 0:
+			field.Set(byKey[key][0])
+		}
+	}
+	return nil
+}
+
+// relationKey returns the key of value, a key column of a relation, to match it with the value of
+// the other side whatever their Go types (an int and an int64, a sql.NullInt64 and an int). It
+// reports false for a zero or NULL value, which references no row.
+func relationKey(value reflect.Value) (string, bool) {
+	if !value.IsValid() || value.IsZero() {
+		return "", false
+	}
+	v := value.Interface()
+	if valuer, ok := v.(driver.Valuer); ok {
+		var err error
+		if v, err = valuer.Value(); err != nil || v == nil {
+			return "", false
+		}
+	}
+	if b, ok := v.([]byte); ok {
+		v = string(b)
+	}
+	return fmt.Sprint(reflect.Indirect(reflect.ValueOf(v)).Interface()), true
+}
@@ -0,0 +1,129 @@
+// Package provenance is a storm plugin that stamps who created and who last updated a row,
+// reading the actor from the context of the session:
+//
+//	err := db.Use(provenance.New(provenance.Config{
+//		Actor: func(ctx context.Context) interface{} { return userIDFrom(ctx) },
+//	}))
+//
+//	type Invoice struct {
+//		ID        int `storm:"pk"`
+//		Total     float64
+//		CreatedBy int64  // set by Insert, when it is zero
+//		UpdatedBy *int64 // set by Insert and Update
+//	}
+//	err = db.WithContext(ctx).Insert(&invoice)
+//
+// The fields can be of any type the actor converts to (an int64 ID into an int, a string
+// into a named string type) or a pointer to it, for nullable columns. Models without the
+// fields are left alone, and so are the changes of a context without actor.
+package provenance
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/pepega90/storm"
+)
+
+// Config configures the provenance plugin, zero values use the default.
+type Config struct {
+	Actor     func(ctx context.Context) interface{} // Actor, the ID of who makes the change, nil when there is none
+	CreatedBy string                                // CreatedBy, Go field name of the creator, default CreatedBy
+	UpdatedBy string                                // UpdatedBy, Go field name of the last updater, default UpdatedBy
+}
+
+// Plugin is the provenance plugin, create it with New and register it with storm.Use.
+type Plugin struct {
+	config Config
+}
+
+// New returns the provenance plugin configured with config.
+func New(config Config) *Plugin {
+	if config.CreatedBy == "" {
+		config.CreatedBy = "CreatedBy"
+	}
+	if config.UpdatedBy == "" {
+		config.UpdatedBy = "UpdatedBy"
+	}
+	return &Plugin{config: config}
+}
+
+// Name implements storm.Plugin.
+func (p *Plugin) Name() string {
+	return "provenance"
+}
+
+// Initialize implements storm.Plugin, it registers the callbacks of the plugin.
+func (p *Plugin) Initialize(s *storm.Storm) error {
+	if p.config.Actor == nil {
+		return fmt.Errorf("provenance: Config.Actor is required")
+	}
+	s.RegisterCallback(storm.HookBeforeInsert, p.stampInsert)
+	s.RegisterCallback(storm.HookBeforeUpdate, p.stampUpdate)
+	return nil
+}
+
+// stampInsert sets the creator and the updater of model, unless they are already set,
+// so imports and seeds can keep their own.
+func (p *Plugin) stampInsert(s *storm.Storm, model interface{}) error {
+	return p.stamp(s, model, func(field string, val reflect.Value) bool {
+		return val.IsZero()
+	})
+}
+
+// stampUpdate sets the updater of model, Update doesn't write the zero creator.
+func (p *Plugin) stampUpdate(s *storm.Storm, model interface{}) error {
+	return p.stamp(s, model, func(field string, val reflect.Value) bool {
+		return field == p.config.UpdatedBy
+	})
+}
+
+// stamp sets the actor of the session into the fields of model that want it.
+func (p *Plugin) stamp(s *storm.Storm, model interface{}, want func(field string, val reflect.Value) bool) error {
+	actor := p.config.Actor(s.Context())
+	if actor == nil {
+		return nil
+	}
+	info, err := s.Schema(model)
+	if err != nil {
+		return nil
+	}
+	val := reflect.ValueOf(model).Elem()
+
+	for _, field := range info.Fields {
+		if field.Name != p.config.CreatedBy && field.Name != p.config.UpdatedBy {
+			continue
+		}
+		if !want(field.Name, field.Value(val)) {
+			continue
+		}
+		if err := set(field.Alloc(val), actor); err != nil {
+			return fmt.Errorf("provenance: %s.%s: %v", info.Type.Name(), field.Name, err)
+		}
+	}
+	return nil
+}
+
+// set sets field, or the value it points to, to actor.
+func set(field reflect.Value, actor interface{}) error {
+	t := field.Type()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	v := reflect.ValueOf(actor)
+	// a number converts to a string as the rune it is, never what we want for an ID
+	if !v.Type().ConvertibleTo(t) || (t.Kind() == reflect.String && v.Kind() != reflect.String) {
+		return fmt.Errorf("cannot set actor %T to %s", actor, field.Type())
+	}
+	v = v.Convert(t)
+
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(t)
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+	field.Set(v)
+	return nil
+}
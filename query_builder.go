@@ -1,37 +1,186 @@
 package storm
 
 import (
+	"database/sql"
 	"fmt"
 	"math"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
 // Query represents a SQL query builder for SELECT operations.
 // It stores the target table, conditions, and pagination options.
 type Query struct {
-	storm         *Storm        // pointer of the orm struct
-	table         string        // table name of the that we want to query, we get it from reflect typeof
-	where         string        // where condition, so what field we want to use to find
-	whereArgument []interface{} // where argument, so we passes the value to the where above
-	limit         int           // limit, use for limit the number of return data from the database
+	storm   *Storm        // pointer of the orm struct
+	table   string        // table name of the that we want to query, we get it from reflect typeof
+	model   interface{}   // model the query was started from, nil when started from Table
+	where   whereClause   // where conditions, so what field we want to use to find, joined with AND
+	limit   int           // limit, use for limit the number of return data from the database
+	offset  int           // offset, number of rows to skip before the first returned one
+	strict  bool          // strict, when true scanning fail on column or field that has no match
+	fields  []string      // fields, Go field names to select, set by SelectFields
+	omit    []string      // omit, Go field names to leave out of the select, set by Omit
+	extra   []rawExpr     // extra, computed expressions selected after the columns, like the ts_rank of RankFullText
+	aliases []string      // aliases, names of the extra expressions, matched against the fields when scanning
+	joins   []rawExpr     // joins, JOIN clauses after the table, like the ones of JoinLateral
+	groupBy []string      // groupBy, GROUP BY columns
+	having  whereClause   // having, HAVING conditions, joined with AND like where
+	orders  []orderTerm   // orders, ORDER BY expressions in order
+	raw     string        // raw, the SQL of a named query, executed as it is instead of the built one
+	rawArgs []interface{} // rawArgs, the arguments of the raw SQL above
+	lock    string        // lock, the locking clause at the end of the SELECT, like the FOR UPDATE NOWAIT of LockRecord
+	preload []string      // preload, Go field names of the associations filled after the query, see Preload
+	err     error         // err, error found while building the query, returned when the query is executed
+
+	withDeleted bool // withDeleted, see the rows a SoftDeletable model marked as deleted
 }
 
 // From initializes a query from the given model struct.
-// It infers the table name based on struct type (structName + "s").
+// It infers the table name based on struct type (structName + "s" with the default naming strategy).
 func (s *Storm) From(model interface{}) *Query {
-	tipe := reflect.TypeOf(model).Elem().Name()
+	if err := checkStruct("model", model); err != nil {
+		return &Query{storm: s, err: err}
+	}
+	tipe := reflect.TypeOf(model).Elem()
 	return &Query{
 		storm: s,
-		table: strings.ToLower(tipe + "s"),
+		table: s.tableName(s.schema.parseType(tipe).Table),
+		model: model,
+	}
+}
+
+// Model is an alias of From, it reads more naturally when the query is about a model
+// instance rather than "selecting from" it, for example db.Model(&user).Where(...).
+func (s *Storm) Model(model interface{}) *Query {
+	return s.From(model)
+}
+
+// Table initializes a query against the table with the given name,
+// so we can query a table that doesn't have a Go struct, or use a different table than the struct name.
+// The destination of Select/First still decide how the columns are mapped.
+func (s *Storm) Table(name string) *Query {
+	return &Query{
+		storm: s,
+		table: s.tableName(name),
 	}
 }
 
 // Where adds a WHERE condition with optional arguments to the query.
 // Example: .Where("id = $1", 10)
-func (q *Query) Where(condition string, args ...interface{}) *Query {
-	q.where = condition
-	q.whereArgument = args
+// A map is expanded into ANDed equality conditions with proper placeholders:
+// .Where(map[string]interface{}{"status": "active", "role": "admin"})
+// A partially filled model use its non-zero fields as ANDed equality conditions:
+// .Where(&User{Email: "a@b.com"})
+// Calling Where several times AND the conditions together, each one is numbered from $1.
+func (q *Query) Where(condition interface{}, args ...interface{}) *Query {
+	expr, err := q.storm.toExpr(condition, args...)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	q.where = append(q.where, expr)
+	return q
+}
+
+// OrWhere combines condition with OR with every condition added before it, it accepts the
+// same conditions as Where. Both sides are wrapped in parentheses, so the precedence is explicit:
+//
+//	.Where("status = $1", "active").Where("age > $1", 18).OrWhere("role = $1", "admin")
+//	// WHERE ((status = $1) AND (age > $2)) OR (role = $3)
+//
+// Use WhereGroup to OR only some of the conditions.
+func (q *Query) OrWhere(condition interface{}, args ...interface{}) *Query {
+	expr, err := q.storm.toExpr(condition, args...)
+	if err != nil {
+		q.err = err
+		return q
+	}
+	if len(q.where) == 0 {
+		q.where = append(q.where, expr)
+		return q
+	}
+	q.where = whereClause{or{and(q.where), expr}}
+	return q
+}
+
+// WhereGroup adds the conditions fn adds to g as one condition wrapped in parentheses,
+// for mixed AND/OR logic with the right precedence:
+//
+//	db.From(&User{}).
+//		Where("tenant_id = $1", tenant).
+//		WhereGroup(func(g *storm.Query) {
+//			g.Where("role = $1", "admin").OrWhere("owner_id = $1", userID)
+//		})
+//	// WHERE (tenant_id = $1) AND ((role = $2) OR (owner_id = $3))
+func (q *Query) WhereGroup(fn func(g *Query)) *Query {
+	g := &Query{storm: q.storm, table: q.table, model: q.model}
+	fn(g)
+	if g.err != nil {
+		q.err = g.err
+		return q
+	}
+	if len(g.where) > 0 {
+		q.where = append(q.where, and(g.where))
+	}
+	return q
+}
+
+// WhereRaw adds a hand written condition for the expressions the DSL can't represent.
+// Every `$?` marker is bound to the next argument, storm numbers them so they merge with the
+// other conditions of the query, whatever the dialect:
+//
+//	.Where("status = $1", "active").WhereRaw("lower(email) = lower($?)", email)
+//	// WHERE (status = $1) AND (lower(email) = lower($2))
+//
+// Markers inside quoted literals are left alone.
+func (q *Query) WhereRaw(condition string, args ...interface{}) *Query {
+	sql, n := numberMarkers(condition)
+	if n != len(args) {
+		q.err = fmt.Errorf("storm: WhereRaw %q has %d markers for %d arguments", condition, n, len(args))
+		return q
+	}
+	q.where = append(q.where, rawExpr{sql, args})
+	return q
+}
+
+// numberMarkers replaces the `$?` markers of sql, outside quoted literals, with $1, $2, ...
+// and returns how many there were.
+func numberMarkers(sql string) (string, int) {
+	var b strings.Builder
+	n := 0
+	inQuote := false
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		if c == '\'' {
+			inQuote = !inQuote
+		}
+		if c == '$' && !inQuote && i+1 < len(sql) && sql[i+1] == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			i++
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String(), n
+}
+
+// WhereExpr adds a condition built with the expression DSL to the query.
+// Example: .WhereExpr(storm.And(storm.Eq{"status": "active"}, storm.Gt{"age": 18}))
+func (q *Query) WhereExpr(expr Expr) *Query {
+	q.where = append(q.where, expr)
+	return q
+}
+
+// Tag attaches key=value to the statements of the query, they are given to the logger in
+// QueryEvent.Tags, so logs, traces and metrics can be attributed to the code that ran them:
+//
+//	db.From(&User{}).Tag("endpoint", "GET /users").Where("status = $1", "active").Select(&users)
+//
+// Tags for a whole request are set once with Session(&SessionConfig{Tags: ...}).
+func (q *Query) Tag(key, value string) *Query {
+	q.storm = q.storm.Session(&SessionConfig{Tags: map[string]string{key: value}})
 	return q
 }
 
@@ -41,222 +190,407 @@ func (q *Query) Limit(n int) *Query {
 	return q
 }
 
-// First executes the query and maps the first matching row into dest struct.
-// You can optionally pass column names to select specific fields.
-func (q *Query) First(dest interface{}, queryCol ...string) error {
-	table := q.table
+// Offset skips the first n rows of the results, use it with an ordering.
+// Example: .OrderByAsc("id").Limit(20).Offset(40) is the third page of 20 rows.
+func (q *Query) Offset(n int) *Query {
+	q.offset = n
+	return q
+}
 
-	isQueryColExist := len(queryCol) > 0
-	selectedCols := "*"
-	if isQueryColExist {
-		selectedCols = strings.Join(queryCol, ",")
-	}
+// Strict turns on strict scanning mode for this query.
+// When enabled, Select, First and Paginate return ErrSchemaMismatch if the result set has
+// a column with no matching struct field, or (when selecting every column) the struct has a
+// field with no matching column. This catches schema drift early instead of silently dropping data.
+// Strict queries match columns with fields by their exact names only, see WithColumnMatcher.
+func (q *Query) Strict() *Query {
+	q.strict = true
+	return q
+}
 
-	query := fmt.Sprintf("SELECT %s FROM %s", selectedCols, table)
+// SelectFields chooses the projected columns by Go field names instead of column names,
+// they are resolved through the model metadata, so .SelectFields("Name") selects name_user.
+// Columns passed directly to Select/First/Paginate take priority over this.
+func (q *Query) SelectFields(fields ...string) *Query {
+	q.fields = append(q.fields, fields...)
+	return q
+}
 
-	var args []interface{}
-	// check if we have WHERE clause
-	if q.where != "" {
-		// if so, then we append the WHERE clause, and query WHERE like for example ID = ?
-		query += " WHERE " + q.where
-		// below we append the WHERE argument value, above the "?" it will become ID we find
-		args = append(args, q.whereArgument...)
-	}
-	query += fmt.Sprintf(" LIMIT %d", 1)
+// SelectAs selects the expression expr under the name alias, after the other columns.
+// When scanning, the alias is matched against the column names of the destination fields,
+// then against the field names (ignoring case and underscores), so renamed and computed
+// columns hydrate without a column tag:
+//
+//	type Post struct {
+//		ID         int `storm:"pk"`
+//		Title      string
+//		AuthorName string
+//	}
+//
+//	db.Table("posts p JOIN users u ON u.id = p.user_id").
+//		SelectAs("u.name_user", "author_name").
+//		Select(&posts, "p.id", "p.title") // SELECT p.id,p.title, u.name_user AS author_name FROM ...
+//
+// expr can have arguments, numbered from $1, like Where.
+func (q *Query) SelectAs(expr, alias string, args ...interface{}) *Query {
+	q.extra = append(q.extra, rawExpr{expr + " AS " + alias, args})
+	q.aliases = append(q.aliases, alias)
+	return q
+}
 
-	rows, err := q.storm.db.Query(query, args...)
-	if err != nil {
-		return err
+// Omit leaves the given Go fields out of the projected columns, every other field is selected.
+// Example: .Omit("Email")
+func (q *Query) Omit(fields ...string) *Query {
+	q.omit = append(q.omit, fields...)
+	return q
+}
+
+// selectColumns resolves the columns to select for a destination struct of type t.
+// Explicit queryCol wins, otherwise SelectFields and Omit are resolved to column names,
+// an empty result means every column (SELECT *).
+// A model with write only fields never select them, so its columns are listed instead of *.
+func (q *Query) selectColumns(queryCol []string, t reflect.Type) ([]string, error) {
+	if len(queryCol) > 0 {
+		return queryCol, nil
 	}
 
-	columnNames, _ := rows.Columns()
+	info := q.storm.schema.parseType(t)
+	if len(q.fields) == 0 && len(q.omit) == 0 && !info.hasWriteOnly() {
+		return queryCol, nil
+	}
+	byName := map[string]*SchemaField{}
+	for _, field := range info.Fields {
+		byName[field.Name] = field
+	}
 
-	vals := make([]interface{}, len(columnNames))
-	for rows.Next() {
-		ptrs := make([]interface{}, len(columnNames))
+	var cols []string
+	if len(q.fields) > 0 {
+		for _, name := range q.fields {
+			field, ok := byName[name]
+			if !ok {
+				return nil, fmt.Errorf("storm: %s has no field %s", t.Name(), name)
+			}
+			cols = append(cols, field.Column)
+		}
+	} else {
+		for _, field := range info.Fields {
+			if !field.writeOnly() {
+				cols = append(cols, field.Column)
+			}
+		}
+	}
 
-		for i := range vals {
-			ptrs[i] = &vals[i]
+	omitted := map[string]bool{}
+	for _, name := range q.omit {
+		field, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("storm: %s has no field %s", t.Name(), name)
 		}
+		omitted[field.Column] = true
+	}
 
-		if err := rows.Scan(ptrs...); err != nil {
-			return err
+	var result []string
+	for _, col := range cols {
+		if !omitted[col] {
+			result = append(result, col)
 		}
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("storm: no column left to select for %s", t.Name())
+	}
+	return result, nil
+}
 
+// First executes the query and maps the first matching row into dest struct.
+// You can optionally pass column names to select specific fields.
+// The row is the first one of the ordering of the query, like OrderByDesc("created_at") for the
+// latest one, and of the primary key when the query has no ordering, so the same row is found every time.
+func (q *Query) First(dest interface{}, queryCol ...string) error {
+	_, err := q.first(dest, queryCol...)
+	return err
+}
+
+// first is First, but it also report whether a row was found at all.
+func (q *Query) first(dest interface{}, queryCol ...string) (bool, error) {
+	found, err := q.scanFirst(dest, queryCol...)
+	if err != nil || !found {
+		return false, err
+	}
+	if err := q.storm.callHook(HookAfterFind, dest); err != nil {
+		return true, err
 	}
+	return true, q.preloadInto([]reflect.Value{reflect.ValueOf(dest).Elem()})
+}
 
-	newStructDestination := reflect.ValueOf(dest).Elem()
-	typeInfo := newStructDestination.Type()
-	ht := map[string]string{}
-	for i := 0; i < newStructDestination.NumField(); i++ {
-		field := typeInfo.Field(i)
+// scanFirst maps the first matching row into dest, without calling the AfterFind hooks.
+func (q *Query) scanFirst(dest interface{}, queryCol ...string) (bool, error) {
+	if q.err != nil {
+		return false, q.err
+	}
 
-		structFieldName := strings.ToLower(field.Name)
+	if err := checkStruct("dest", dest); err != nil {
+		return false, err
+	}
 
-		if val, ok := field.Tag.Lookup("storm"); ok {
-			stormTagSplit := strings.Split(val, ":")
-			if len(stormTagSplit) == 2 {
-				structFieldName = stormTagSplit[1]
-			}
-		}
+	queryCol, err := q.selectColumns(queryCol, reflect.TypeOf(dest).Elem())
+	if err != nil {
+		return false, err
+	}
 
-		ht[structFieldName] = field.Name
+	first := *q
+	if orders := q.pkOrder(reflect.TypeOf(dest).Elem(), queryCol); orders != nil {
+		first.orders = orders
+	}
+	query, args, err := first.buildSelect(queryCol, 1)
+	if err != nil {
+		return false, err
 	}
 
-	for i, col := range columnNames {
-		structFieldName, ok := ht[col]
-		if !ok {
-			continue
+	found := false
+	err = q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		columnNames, err := rows.Columns()
+		if err != nil {
+			return err
 		}
 
-		field := newStructDestination.FieldByName(structFieldName)
+		newStructDestination := reflect.ValueOf(dest).Elem()
+		fields := q.fieldsFor(newStructDestination.Type(), columnNames)
 
-		if !field.IsValid() {
-			continue
+		if !rows.Next() {
+			return nil
 		}
 
-		// in here we set the value, from database
-		err := setFieldValue(field, vals[i])
+		vals, err := scanValues(rows, len(columnNames))
 		if err != nil {
 			return err
 		}
-	}
 
-	return nil
+		// in here we set the value, from database
+		found = true
+		return q.mapRow(newStructDestination, columnNames, vals, fields, len(queryCol) == 0)
+	})
+	return found, err
 }
 
 // Select executes the query and maps all rows into a slice of structs.
 // Example usage: var users []User; db.From(&User{}).Select(&users)
+// dest can also be a slice of an interface registered with RegisterTypes, or of any interface
+// for a model with subtypes, see RegisterSubtypes.
 func (q *Query) Select(dest interface{}, queryCol ...string) error {
-	// below we got tipe of sturct, we do Elem() twice to get that, cause if we only do Elem() one, we got slice value, so for example User struct, we got []User
-	tipe := reflect.TypeOf(dest).Elem().Elem()
-	table := q.table
-
-	isQueryColExist := len(queryCol) > 0
-	selectedCols := "*"
-	if isQueryColExist {
-		selectedCols = strings.Join(queryCol, ",")
+	if q.err != nil {
+		return q.err
+	}
+	if poly := q.storm.polymorphicOf(dest, q.model); poly != nil {
+		return q.selectPolymorphic(dest, poly, queryCol)
 	}
 
-	query := fmt.Sprintf("SELECT %s FROM %s", selectedCols, table)
+	if err := checkSlice(dest); err != nil {
+		return err
+	}
 
-	var args []interface{}
-	// check if we have WHERE clause
-	if q.where != "" {
-		// if so, then we append the WHERE clause, and query WHERE like for example ID = ?
-		query += " WHERE " + q.where
-		// below we append the WHERE argument value, above the "?" it will become ID we find
-		args = append(args, q.whereArgument...)
+	queryCol, err := q.selectColumns(queryCol, reflect.TypeOf(dest).Elem().Elem())
+	if err != nil {
+		return err
 	}
 
-	// check if limit apply
-	if q.limit > 0 {
-		query += fmt.Sprintf(" LIMIT %d", q.limit)
+	query, args, err := q.buildSelect(queryCol, q.limit)
+	if err != nil {
+		return err
 	}
 
-	rows, err := q.storm.db.Query(query, args...)
+	err = q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		return q.scanAll(rows, dest, len(queryCol) == 0)
+	})
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	return q.preloadInto(sliceValues(reflect.ValueOf(dest).Elem()))
+}
 
-	// below we got list of the column name
-	cols, _ := rows.Columns()
-	// sliceVal, we reflect value of dest params, it will be empty slice since we will fill it with value of the struct we do reflectTypeOf(dest).Elem().Elem() above
-	// for example if dest is *[]User then it will be []User
-	sliceVal := reflect.ValueOf(dest).Elem()
+// sliceValues returns the elements of slice, addressable since they are elements of a slice.
+func sliceValues(slice reflect.Value) []reflect.Value {
+	values := make([]reflect.Value, slice.Len())
+	for i := range values {
+		values[i] = slice.Index(i)
+	}
+	return values
+}
 
-	for rows.Next() {
-		/*
-			vals, is for actual value in the database
-			ptrs, is for pointing to each value in vals[i] at i index
-			for example if vals have 3 column (id name email), then it will be:
-			vals = {nil nil nil}
-			ptrs = {nil nil nil}
-		*/
-		vals := make([]interface{}, len(cols))
-		ptrs := make([]interface{}, len(cols))
-
-		// then we use ptrs at index i we give pointer of value
-		// so ptrs will be ptrs = {&vals[0], &vals[1], &vals[2]}
-		for i := range vals {
-			ptrs[i] = &vals[i]
-		}
-
-		// after that we scan it, the vals with get the data since its pointer to ptrs at index i
-		if err := rows.Scan(ptrs...); err != nil {
-			return err
-		}
+// DeleteReturning deletes the rows matching the query and maps the deleted rows into dest,
+// a pointer to slice of struct, with a single DELETE ... RETURNING statement.
+// Example: db.From(&User{}).Where("status = $1", "banned").DeleteReturning(&deleted)
+// Like DeleteFrom it refuses to run without a WHERE condition (ErrMissingWhereClause).
+// The rows of a SoftDeletable model are only marked as deleted, like Delete, with an
+// UPDATE ... RETURNING, unless the session is Unscoped.
+// RETURNING is supported by postgres and sqlite, not by mysql.
+func (q *Query) DeleteReturning(dest interface{}, queryCol ...string) error {
+	if q.err != nil {
+		return q.err
+	}
+	if q.raw != "" {
+		return fmt.Errorf("storm: DeleteReturning is not supported for named queries")
+	}
+	if q.storm.dialect.Name() == "mysql" {
+		return fmt.Errorf("storm: DeleteReturning is not supported on mysql")
+	}
 
-		// we create struct of type reflect.TypeOf above
-		newStruct := reflect.New(tipe).Elem()
-		newStructType := newStruct.Type()
+	if err := checkSlice(dest); err != nil {
+		return err
+	}
 
-		// so below we create key value pair, of column name and field in the struct. cause if we change the column name in the db, its will not following the struct field name anymore.
-		/*
-			for example
+	queryCol, err := q.selectColumns(queryCol, reflect.TypeOf(dest).Elem().Elem())
+	if err != nil {
+		return err
+	}
 
-			type User struct {
-				Name string
-				Email string
-			}
+	where, args, err := q.where.build(0)
+	if err != nil {
+		return err
+	}
+	if where == "" {
+		return ErrMissingWhereClause
+	}
 
-			in database is
-			| id | name_user | email_user |
+	returning := "*"
+	if len(queryCol) > 0 {
+		returning = strings.Join(queryCol, ",")
+	}
 
-			so is not match right, so hash_map will look like this
+	var query string
+	if soft, ok := q.model.(SoftDeletable); ok && !q.storm.unscoped {
+		// the rows are only marked as deleted, like Delete does, softDelete adds the scopes
+		conds := q.where
+		if expr := q.storm.discriminatorExpr(q.model); expr != nil {
+			conds = append(conds[:len(conds):len(conds)], expr)
+		}
+		if query, args, err = q.storm.softDelete(soft, q.table, conds); err != nil {
+			return err
+		}
+		query += " RETURNING " + returning
+	} else {
+		if where, args, err = q.conditions(q.where).build(0); err != nil {
+			return err
+		}
+		query = fmt.Sprintf("DELETE FROM %s WHERE %s RETURNING %s", q.table, where, returning)
+	}
 
-			{
-				name_user: Name,
-				email_user: Email
-			}
+	err = q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		return q.scanAll(rows, dest, len(queryCol) == 0)
+	})
+	if err != nil {
+		return err
+	}
+	q.storm.forgetCachedTable(q.table)
+	return nil
+}
 
-			like so, so if we alter or rename the name of the field in the DB, we still got that
-		*/
+// buildSelect builds the SELECT statement of this query and its arguments,
+// limit is only applied when its greater than 0, like the offset of the query.
+// For a raw (named) query the registered SQL is returned as it is.
+func (q *Query) buildSelect(queryCol []string, limit int) (string, []interface{}, error) {
+	if q.raw != "" {
+		return q.raw, q.rawArgs, nil
+	}
 
-		ht := map[string]string{}
-		for i := 0; i < newStructType.NumField(); i++ {
-			field := newStructType.Field(i)
+	selectedCols := "*"
+	if len(queryCol) > 0 {
+		selectedCols = strings.Join(queryCol, ",")
+	}
 
-			col := strings.ToLower(field.Name)
+	// computed expressions come first in the statement, so their arguments are numbered first
+	var args []interface{}
+	for _, e := range q.extra {
+		selectedCols += ", " + shiftPlaceholders(e.sql, len(args))
+		args = append(args, e.args...)
+	}
 
-			// if "storm" tag exists, extract "column:xxx"
-			if tag, ok := field.Tag.Lookup("storm"); ok {
-				parts := strings.Split(tag, ":")
-				if len(parts) == 2 && parts[0] == "column" {
-					col = parts[1]
-				}
-			}
-			ht[col] = field.Name
-		}
+	query := fmt.Sprintf("SELECT %s FROM %s", selectedCols, q.table)
 
-		for i, col := range cols {
-			structFieldName, ok := ht[col]
-			if !ok {
-				continue
-			}
+	// joins come right after the table, their arguments before the WHERE ones
+	for _, j := range q.joins {
+		query += " " + shiftPlaceholders(j.sql, len(args))
+		args = append(args, j.args...)
+	}
 
-			// FieldByName, its find name that match with col name from cols, its case-insensitive
-			field := newStruct.FieldByName(structFieldName)
+	// check if we have WHERE clause, the global scopes are part of it
+	where, whereArgs, err := q.conditions(q.where).build(len(args))
+	if err != nil {
+		return "", nil, err
+	}
+	if where != "" {
+		// if so, then we append the WHERE clause, and query WHERE like for example ID = $1
+		query += " WHERE " + where
+		args = append(args, whereArgs...)
+	}
 
-			if !field.IsValid() {
-				continue
-			}
+	if len(q.groupBy) > 0 {
+		query += " GROUP BY " + strings.Join(q.groupBy, ", ")
+	}
 
-			err := setFieldValue(field, vals[i])
-			if err != nil {
-				return fmt.Errorf("error setting field %s: %v", ht[col], err)
-			}
+	// HAVING arguments continue the numbering after the WHERE ones
+	having, havingArgs, err := q.having.build(len(args))
+	if err != nil {
+		return "", nil, err
+	}
+	if having != "" {
+		query += " HAVING " + having
+		args = append(args, havingArgs...)
+	}
+
+	if len(q.orders) > 0 {
+		var orders []string
+		for _, o := range q.orders {
+			order, orderArgs := o.sql(q.storm.dialect.Name(), len(args))
+			orders = append(orders, order)
+			args = append(args, orderArgs...)
 		}
-		sliceVal.Set(reflect.Append(sliceVal, newStruct))
+		query += " ORDER BY " + strings.Join(orders, ", ")
 	}
-	return nil
+
+	// LIMIT and OFFSET are bound like any other value, so the statement text doesn't change with
+	// them (prepared statement caches work) and nothing is formatted into the SQL
+	bound := &params{args: args}
+	if limit > 0 {
+		query += " LIMIT " + bound.add(limit)
+	} else if q.offset > 0 && q.storm.dialect.Name() == "mysql" {
+		// mysql has no OFFSET without LIMIT, its documentation use the biggest LIMIT possible
+		query += " LIMIT 18446744073709551615"
+	}
+	if q.offset > 0 {
+		query += " OFFSET " + bound.add(q.offset)
+	}
+	if q.lock != "" {
+		query += " " + q.lock
+	}
+
+	return query, bound.args, nil
 }
 
 // Paginate executes the query with pagination support.
 // It fills dest with results, and also updates total and totalPages values.
+//
+// Deprecated: use PaginatePage, which returns the results and the totals together in a Page.
 func (q *Query) Paginate(dest interface{}, page, pageSize int, total *int, totalPages *int, queryCol ...string) error {
-	tipe := reflect.TypeOf(dest).Elem().Elem()
+	p, err := q.paginate(dest, page, pageSize, queryCol...)
+	if err != nil {
+		return err
+	}
+	*total = p.Total
+	*totalPages = p.TotalPages
+	return nil
+}
+
+// paginate fills dest with one page of results and returns the page information (everything except Items).
+func (q *Query) paginate(dest interface{}, page, pageSize int, queryCol ...string) (*Page, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+	if q.raw != "" {
+		return nil, fmt.Errorf("storm: Paginate is not supported for named queries")
+	}
+	if err := checkSlice(dest); err != nil {
+		return nil, err
+	}
+
 	if page < 1 {
 		page = 1
 	}
@@ -265,129 +599,269 @@ func (q *Query) Paginate(dest interface{}, page, pageSize int, total *int, total
 		pageSize = 1
 	}
 
-	// count total of data
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s", q.table)
-	if err := q.storm.db.QueryRow(countQuery).Scan(total); err != nil {
-		return err
+	result := &Page{Page: page, PageSize: pageSize}
+
+	// the total shares the joins and the conditions of the query, with the global scopes
+	// and the soft deletes
+	total, err := q.total()
+	if err != nil {
+		return nil, err
 	}
+	result.Total = total
 
 	// calculate total pages
-	*totalPages = int(math.Ceil(float64(*total) / float64(pageSize)))
+	result.TotalPages = int(math.Ceil(float64(result.Total) / float64(pageSize)))
+	result.HasNext = page < result.TotalPages
 
-	isQueryColExist := len(queryCol) > 0
-	selectedCols := "*"
-	if isQueryColExist {
-		selectedCols = strings.Join(queryCol, ",")
+	queryCol, err = q.selectColumns(queryCol, reflect.TypeOf(dest).Elem().Elem())
+	if err != nil {
+		return nil, err
 	}
 
-	offset := (page - 1) * pageSize
-	query := fmt.Sprintf("SELECT %s FROM %s ORDER BY id LIMIT $1 OFFSET $2", selectedCols, q.table)
+	// the pages follow the ordering of the query, by the primary key (or id) when it has none,
+	// a grouped query has no id to order by, its own ordering is kept as it is
+	pager := *q
+	if orders := q.pkOrder(reflect.TypeOf(dest).Elem().Elem(), queryCol); orders != nil {
+		pager.orders = orders
+	} else if len(pager.orders) == 0 && len(pager.groupBy) == 0 {
+		pager.orders = []orderTerm{{expr: rawExpr{q.qualified("id"), nil}}}
+	}
+	pager.offset = (page - 1) * pageSize
+	query, args, err := pager.buildSelect(queryCol, pageSize)
+	if err != nil {
+		return nil, err
+	}
 
-	rows, err := q.storm.db.Query(query, pageSize, offset)
+	err = q.storm.queryRows(query, args, func(rows *sql.Rows) error {
+		return q.scanAll(rows, dest, len(queryCol) == 0)
+	})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer rows.Close()
+	if err := q.preloadInto(sliceValues(reflect.ValueOf(dest).Elem())); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// scanAll maps every row of rows into a new struct and appends it to dest,
+// dest must be pointer to slice of struct, for example *[]User.
+// allColumns tells whether the query selected every column (SELECT *), strict mode use it
+// to know if a struct field without column is a schema drift or just not selected.
+func (q *Query) scanAll(rows *sql.Rows, dest interface{}, allColumns bool) error {
+	// below we got tipe of sturct, we do Elem() twice to get that, cause if we only do Elem() one, we got slice value, so for example User struct, we got []User
+	tipe := reflect.TypeOf(dest).Elem().Elem()
 
 	// below we got list of the column name
-	cols, _ := rows.Columns()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
 	// sliceVal, we reflect value of dest params, it will be empty slice since we will fill it with value of the struct we do reflectTypeOf(dest).Elem().Elem() above
 	// for example if dest is *[]User then it will be []User
 	sliceVal := reflect.ValueOf(dest).Elem()
 
+	// the column to field mapping is the same for every row, so we only build it once
+	fields := q.fieldsFor(tipe, cols)
+
 	for rows.Next() {
-		/*
-			vals, is for actual value in the database
-			ptrs, is for pointing to each value in vals[i] at i index
-			for example if vals have 3 column (id name email), then it will be:
-			vals = {nil nil nil}
-			ptrs = {nil nil nil}
-		*/
-		vals := make([]interface{}, len(cols))
-		ptrs := make([]interface{}, len(cols))
-
-		// then we use ptrs at index i we give pointer of value
-		// so ptrs will be ptrs = {&vals[0], &vals[1], &vals[2]}
-		for i := range vals {
-			ptrs[i] = &vals[i]
-		}
-
-		// after that we scan it, the vals with get the data since its pointer to ptrs at index i
-		if err := rows.Scan(ptrs...); err != nil {
+		vals, err := scanValues(rows, len(cols))
+		if err != nil {
 			return err
 		}
 
 		// we create struct of type reflect.TypeOf above
 		newStruct := reflect.New(tipe).Elem()
-		newStructType := newStruct.Type()
 
-		// so below we create key value pair, of column name and field in the struct. cause if we change the column name in the db, its will not following the struct field name anymore.
-		/*
-			for example
+		if err := q.mapRow(newStruct, cols, vals, fields, allColumns); err != nil {
+			return err
+		}
+		if err := q.storm.callHook(HookAfterFind, newStruct.Addr().Interface()); err != nil {
+			return err
+		}
+		sliceVal.Set(reflect.Append(sliceVal, newStruct))
+	}
+	return rows.Err()
+}
+
+// scanValues scans the current row into a slice of raw values, one per column.
+func scanValues(rows *sql.Rows, n int) ([]interface{}, error) {
+	/*
+		vals, is for actual value in the database
+		ptrs, is for pointing to each value in vals[i] at i index
+		for example if vals have 3 column (id name email), then it will be:
+		vals = {nil nil nil}
+		ptrs = {nil nil nil}
+	*/
+	vals := make([]interface{}, n)
+	ptrs := make([]interface{}, n)
+
+	// then we use ptrs at index i we give pointer of value
+	// so ptrs will be ptrs = {&vals[0], &vals[1], &vals[2]}
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
 
-			type User struct {
-				Name string
-				Email string
-			}
+	// after that we scan it, the vals with get the data since its pointer to ptrs at index i
+	if err := rows.Scan(ptrs...); err != nil {
+		return nil, err
+	}
+	return vals, nil
+}
 
-			in database is
-			| id | name_user | email_user |
+// fieldsByColumn creates key value pair, of column name and field in the struct.
+// cause if we change the column name in the db, its will not following the struct field name anymore.
+/*
+	for example
 
-			so is not match right, so hash_map will look like this
+	type User struct {
+		Name string
+		Email string
+	}
 
-			{
-				name_user: Name,
-				email_user: Email
-			}
+	in database is
+	| id | name_user | email_user |
 
-			like so, so if we alter or rename the name of the field in the DB, we still got that
-		*/
+	so is not match right, so hash_map will look like this
 
-		ht := map[string]string{}
-		for i := 0; i < newStructType.NumField(); i++ {
-			field := newStructType.Field(i)
+	{
+		name_user: Name,
+		email_user: Email
+	}
 
-			col := strings.ToLower(field.Name)
+	(the value is the parsed field, so we also know its index and tag)
 
-			// if "storm" tag exists, extract "column:xxx"
-			if tag, ok := field.Tag.Lookup("storm"); ok {
-				parts := strings.Split(tag, ":")
-				if len(parts) == 2 && parts[0] == "column" {
-					col = parts[1]
-				}
+	like so, so if we alter or rename the name of the field in the DB, we still got that
+*/
+func (s *Storm) fieldsByColumn(t reflect.Type) map[string]*SchemaField {
+	ht := map[string]*SchemaField{}
+	for _, field := range s.schema.parseType(t).Fields {
+		ht[field.Column] = field
+	}
+	return ht
+}
+
+// fieldsFor is fieldsByColumn of the destination type t, plus the aliases of the query
+// (see SelectAs) that match a field by its name, plus the cols of the result that have no
+// field with their exact name but match one with the column matcher (see WithColumnMatcher).
+// Strict queries only use the exact names.
+func (q *Query) fieldsFor(t reflect.Type, cols []string) map[string]*SchemaField {
+	fields := q.storm.fieldsByColumn(t)
+	info := q.storm.schema.parseType(t)
+	for _, alias := range q.aliases {
+		if _, ok := fields[alias]; ok {
+			continue
+		}
+		name := strings.ReplaceAll(alias, "_", "")
+		for _, field := range info.Fields {
+			if strings.EqualFold(field.Name, name) {
+				fields[alias] = field
+				break
 			}
-			ht[col] = field.Name
 		}
+	}
 
-		for i, col := range cols {
-			structFieldName, ok := ht[col]
-			if !ok {
-				continue
+	if q.strict || q.storm.matcher == nil {
+		return fields
+	}
+
+	// a field already matched by a column of the result is not matched again
+	taken := map[*SchemaField]bool{}
+	for _, col := range cols {
+		if field, ok := fields[col]; ok {
+			taken[field] = true
+		}
+	}
+	for _, col := range cols {
+		if _, ok := fields[col]; ok {
+			continue
+		}
+		for _, field := range info.Fields {
+			if !taken[field] && q.storm.matcher(col, field) {
+				fields[col] = field
+				taken[field] = true
+				break
 			}
+		}
+	}
+	return fields
+}
 
-			// FieldByName, its find name that match with col name from cols, its case-insensitive
-			field := newStruct.FieldByName(structFieldName)
+// mapRow sets the scanned vals into the matching fields of dest struct.
+// In strict mode a column without field, or a field without column (when every column was selected),
+// is returned as ErrSchemaMismatch instead of silently dropped.
+func (q *Query) mapRow(dest reflect.Value, cols []string, vals []interface{}, fields map[string]*SchemaField, allColumns bool) error {
+	matched := make(map[*SchemaField]bool, len(cols))
+	for i, col := range cols {
+		info, ok := fields[col]
+		if !ok {
+			if q.strict {
+				return fmt.Errorf("%w: column %q has no matching field in %s", ErrSchemaMismatch, col, dest.Type().Name())
+			}
+			continue
+		}
 
-			if !field.IsValid() {
+		matched[info] = true
+		// write only fields are never read back, even when the column is selected
+		if info.writeOnly() {
+			continue
+		}
+
+		// encrypted fields are decrypted before they are set
+		value, err := q.storm.readValue(info, vals[i])
+		if err != nil {
+			return err
+		}
+
+		// a nil embedded pointer is only allocated for a column that is not NULL
+		field := info.Value(dest)
+		if !field.CanSet() {
+			if value == nil {
 				continue
 			}
+			field = info.Alloc(dest)
+		}
 
-			err := setFieldValue(field, vals[i])
-			if err != nil {
-				return fmt.Errorf("error setting field %s: %v", ht[col], err)
+		err = setFieldValue(field, value)
+		if err != nil {
+			return fmt.Errorf("error setting field %s: %v", info.Name, err)
+		}
+	}
+
+	if q.strict && allColumns {
+		// a field matched through an alias doesn't need its own column
+		for col, field := range fields {
+			if !matched[field] {
+				return fmt.Errorf("%w: field %s.%s has no matching column %q", ErrSchemaMismatch, dest.Type().Name(), field.Name, col)
 			}
 		}
-		sliceVal.Set(reflect.Append(sliceVal, newStruct))
 	}
 	return nil
 }
 
 // setFieldValue, private function for set value for each struct field have 2 parameter field is the field we want to set the  value, and value itself
 func setFieldValue(field reflect.Value, value interface{}) error {
+	// types that know how to read themselves, like sql.NullString or Range, do it on their own
+	if field.CanAddr() {
+		if scanner, ok := field.Addr().Interface().(sql.Scanner); ok {
+			return scanner.Scan(value)
+		}
+	}
+
 	if value == nil {
 		return nil
 	}
 
+	// nullable columns are usually pointer fields, like *string, we set the pointed value
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFieldValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
 	fieldType := field.Type()
 	val := reflect.ValueOf(value)
 
@@ -396,6 +870,29 @@ func setFieldValue(field reflect.Value, value interface{}) error {
 		return nil
 	}
 
+	// json columns can come as text, json.RawMessage keeps a copy of it as it is
+	if fieldType == rawMessageType {
+		if text, ok := value.(string); ok {
+			field.SetBytes([]byte(text))
+			return nil
+		}
+	}
+
+	// postgres interval columns come as text, like "01:30:00" or "2 days 03:00:00"
+	if fieldType == durationType {
+		if b, ok := value.([]byte); ok {
+			value = string(b)
+		}
+		if text, ok := value.(string); ok {
+			d, err := parseInterval(text)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+	}
+
 	switch field.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		switch v := value.(type) {
@@ -0,0 +1,172 @@
+package storm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range is a postgres range value, like int4range, int8range, numrange, tsrange, tstzrange or daterange.
+// T can be int, int32, int64, float64, string or time.Time. Use it as a model field to read and
+// write range columns, and with Overlaps and Contains in conditions.
+type Range[T any] struct {
+	Lower    T
+	Upper    T
+	LowerInc bool // LowerInc, the lower bound is inclusive, `[`
+	UpperInc bool // UpperInc, the upper bound is inclusive, `]`
+	LowerInf bool // LowerInf, there is no lower bound, Lower is ignored
+	UpperInf bool // UpperInf, there is no upper bound, Upper is ignored
+	Empty    bool // Empty, the range contains nothing
+}
+
+// NewRange returns the range [lower, upper), the canonical form postgres use for discrete ranges.
+func NewRange[T any](lower, upper T) Range[T] {
+	return Range[T]{Lower: lower, Upper: upper, LowerInc: true}
+}
+
+// Value implements driver.Valuer, it writes r as range literal like `[1,10)`.
+func (r Range[T]) Value() (driver.Value, error) {
+	if r.Empty {
+		return "empty", nil
+	}
+
+	var b strings.Builder
+	if r.LowerInc && !r.LowerInf {
+		b.WriteByte('[')
+	} else {
+		b.WriteByte('(')
+	}
+	if !r.LowerInf {
+		b.WriteString(formatRangeBound(r.Lower))
+	}
+	b.WriteByte(',')
+	if !r.UpperInf {
+		b.WriteString(formatRangeBound(r.Upper))
+	}
+	if r.UpperInc && !r.UpperInf {
+		b.WriteByte(']')
+	} else {
+		b.WriteByte(')')
+	}
+	return b.String(), nil
+}
+
+// Scan implements sql.Scanner, it reads a range literal like `[1,10)` or `empty`.
+// NULL scans to the zero Range.
+func (r *Range[T]) Scan(src interface{}) error {
+	*r = Range[T]{}
+
+	var text string
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("storm: cannot scan %T into a Range", src)
+	}
+
+	if text == "empty" {
+		r.Empty = true
+		return nil
+	}
+	if len(text) < 3 {
+		return fmt.Errorf("storm: invalid range %q", text)
+	}
+
+	r.LowerInc = text[0] == '['
+	r.UpperInc = text[len(text)-1] == ']'
+	lower, upper, ok := strings.Cut(text[1:len(text)-1], ",")
+	if !ok {
+		return fmt.Errorf("storm: invalid range %q", text)
+	}
+
+	var err error
+	if lower == "" {
+		r.LowerInf = true
+	} else if err = parseRangeBound(unquoteRangeBound(lower), &r.Lower); err != nil {
+		return err
+	}
+	if upper == "" {
+		r.UpperInf = true
+	} else if err = parseRangeBound(unquoteRangeBound(upper), &r.Upper); err != nil {
+		return err
+	}
+	return nil
+}
+
+// formatRangeBound writes a bound of a range literal, quoted when it may contain spaces or commas.
+func formatRangeBound(v interface{}) string {
+	switch v := v.(type) {
+	case time.Time:
+		return `"` + v.Format(time.RFC3339Nano) + `"`
+	case string:
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+	}
+	return fmt.Sprint(v)
+}
+
+// unquoteRangeBound removes the double quotes postgres puts around some bounds, like timestamps.
+func unquoteRangeBound(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return strings.NewReplacer(`\\`, `\`, `\"`, `"`).Replace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// rangeTimeLayouts, are the text formats postgres use for the bounds of tsrange, tstzrange and daterange
+var rangeTimeLayouts = []string{
+	"2006-01-02 15:04:05.999999-07",
+	"2006-01-02 15:04:05.999999-07:00",
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+// parseRangeBound parses the text of a bound into dest.
+func parseRangeBound(s string, dest interface{}) error {
+	var err error
+	switch d := dest.(type) {
+	case *int:
+		*d, err = strconv.Atoi(s)
+	case *int32:
+		var n int64
+		n, err = strconv.ParseInt(s, 10, 32)
+		*d = int32(n)
+	case *int64:
+		*d, err = strconv.ParseInt(s, 10, 64)
+	case *float64:
+		*d, err = strconv.ParseFloat(s, 64)
+	case *string:
+		*d = s
+	case *time.Time:
+		for _, layout := range rangeTimeLayouts {
+			if *d, err = time.Parse(layout, s); err == nil {
+				return nil
+			}
+		}
+	default:
+		return fmt.Errorf("storm: unsupported range element type %T", dest)
+	}
+	if err != nil {
+		return fmt.Errorf("storm: invalid range bound %q: %v", s, err)
+	}
+	return nil
+}
+
+// Overlaps is the condition that the range column overlaps the range value, with the `&&` operator.
+// Example: Overlaps("during", storm.NewRange(from, to)) is `during && $1`.
+func Overlaps(column string, value interface{}) Expr {
+	return rawExpr{fmt.Sprintf("%s && $1", column), []interface{}{value}}
+}
+
+// Contains is the condition that the range column contains value, with the `@>` operator.
+// value can be a single element, like a time.Time, or a range.
+// Example: Contains("during", time.Now()) is `during @> $1`.
+func Contains(column string, value interface{}) Expr {
+	return rawExpr{fmt.Sprintf("%s @> $1", column), []interface{}{value}}
+}
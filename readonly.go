@@ -0,0 +1,48 @@
+package storm
+
+import "strings"
+
+// ReadOnly returns a session that refuses to write, a shortcut of Session(&SessionConfig{ReadOnly: true}).
+// Insert, Update, Delete, the builders and every other statement that is not a read return
+// ErrReadOnly without reaching the database, and its transactions are started READ ONLY, so
+// reporting code paths can't write even by mistake:
+//
+//	reports := db.ReadOnly()
+//	err := reports.From(&Order{}).Where("total > $1", 100).Select(&orders) // fine
+//	err = reports.Insert(&order)                                           // ErrReadOnly
+//
+// A WITH statement only runs in a transaction of the session, which is READ ONLY, since its
+// CTEs can write. Statements sent through DB() directly are not checked.
+func (s *Storm) ReadOnly() *Storm {
+	return s.Session(&SessionConfig{ReadOnly: true})
+}
+
+// isReadStatement reports whether query only reads, by its first keyword: SELECT, SHOW or
+// EXPLAIN without ANALYZE, which would run the statement. A WITH can hide a write in its CTEs
+// and a SET can change the transaction to READ WRITE, so they are not reads.
+func isReadStatement(query string) bool {
+	words := strings.Fields(query)
+	if len(words) == 0 {
+		return false
+	}
+	switch strings.ToUpper(words[0]) {
+	case "SELECT", "SHOW":
+		return true
+	case "EXPLAIN":
+		return len(words) < 2 || !strings.Contains(strings.ToUpper(words[1]), "ANALYZE")
+	}
+	return false
+}
+
+// refuses reports whether this handle refuses to run query, because it is a read only session
+// and query may write. In the READ ONLY transaction of a read only session the database refuses
+// the writes itself, so a WITH can run there.
+func (s *Storm) refuses(query string) bool {
+	if !s.readOnly || isReadStatement(query) {
+		return false
+	}
+	if words := strings.Fields(query); s.readOnlyTx && len(words) > 0 && strings.EqualFold(words[0], "WITH") {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,238 @@
+// Package rediscache is a storm.Cache backed by Redis.
+//
+//	cache := rediscache.New(rediscache.Options{Addr: "localhost:6379"})
+//	db, err := storm.New("postgres", dsn, storm.WithCache(cache, 10*time.Minute))
+//
+// It speaks the Redis protocol (RESP) directly over a small pool of connections,
+// so it has no dependency other than the standard library.
+package rediscache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/pepega90/storm"
+)
+
+// Options configures the connection to Redis, zero values use the default.
+type Options struct {
+	Addr        string        // Addr, host:port of the server, default localhost:6379
+	Password    string        // Password, sent with AUTH when not empty
+	DB          int           // DB, database number selected with SELECT
+	Prefix      string        // Prefix, added to every key, to share a server between applications
+	DialTimeout time.Duration // DialTimeout, default 5 seconds
+	PoolSize    int           // PoolSize, maximum number of idle connections kept, default 10
+}
+
+// Cache is a storm.Cache stored in Redis, it is safe for concurrent use.
+type Cache struct {
+	opts Options
+
+	mu   sync.Mutex
+	idle []*conn
+}
+
+// Cache must always satisfy storm.Cache
+var _ storm.Cache = (*Cache)(nil)
+
+// conn, is a connection to redis with its buffered reader
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+// errNil, is the reply of redis for a missing key
+var errNil = errors.New("rediscache: nil")
+
+// New returns a Cache connecting to Redis with opts, connections are opened when needed.
+func New(opts Options) *Cache {
+	if opts.Addr == "" {
+		opts.Addr = "localhost:6379"
+	}
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = 5 * time.Second
+	}
+	if opts.PoolSize == 0 {
+		opts.PoolSize = 10
+	}
+	return &Cache{opts: opts}
+}
+
+// Get implements storm.Cache.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := c.do(ctx, "GET", c.opts.Prefix+key)
+	if err == errNil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("rediscache: unexpected reply %v to GET", reply)
+	}
+	return value, true, nil
+}
+
+// Set implements storm.Cache.
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", c.opts.Prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := c.do(ctx, args...)
+	return err
+}
+
+// Delete implements storm.Cache.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	_, err := c.do(ctx, "DEL", c.opts.Prefix+key)
+	return err
+}
+
+// Close closes the idle connections.
+func (c *Cache) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, cn := range c.idle {
+		cn.Close()
+	}
+	c.idle = nil
+	return nil
+}
+
+// do sends the command args and returns its reply, a []byte, int64 or string.
+func (c *Cache) do(ctx context.Context, args ...string) (interface{}, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		cn.SetDeadline(deadline)
+	} else {
+		cn.SetDeadline(time.Time{})
+	}
+
+	reply, err := cn.command(args...)
+	// a redis error reply leaves the connection usable, anything else may not
+	var redisErr redisError
+	if err != nil && err != errNil && !errors.As(err, &redisErr) {
+		cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// get returns an idle connection, or opens a new one.
+func (c *Cache) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	dialer := net.Dialer{Timeout: c.opts.DialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.opts.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("rediscache: %v", err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+
+	if c.opts.Password != "" {
+		if _, err := cn.command("AUTH", c.opts.Password); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if c.opts.DB != 0 {
+		if _, err := cn.command("SELECT", strconv.Itoa(c.opts.DB)); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+// put gives cn back to the pool, or closes it when the pool is full.
+func (c *Cache) put(cn *conn) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.idle) >= c.opts.PoolSize {
+		cn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// redisError, is an error reply of redis, like WRONGTYPE
+type redisError string
+
+func (e redisError) Error() string {
+	return "rediscache: " + string(e)
+}
+
+// command writes args as a RESP array of bulk strings and reads the reply.
+func (cn *conn) command(args ...string) (interface{}, error) {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	if _, err := cn.Write(buf); err != nil {
+		return nil, err
+	}
+	return cn.reply()
+}
+
+// reply reads one RESP reply, arrays are not used by the cache commands.
+func (cn *conn) reply() (interface{}, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("rediscache: invalid reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("rediscache: invalid bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, errNil
+		}
+		value := make([]byte, n+2)
+		if _, err := io.ReadFull(cn.r, value); err != nil {
+			return nil, err
+		}
+		return value[:n], nil
+	}
+	return nil, fmt.Errorf("rediscache: unsupported reply %q", line)
+}
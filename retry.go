@@ -0,0 +1,103 @@
+package storm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RetryPolicy configures how the SELECTs are retried on connection errors, see WithRetry.
+type RetryPolicy struct {
+	Attempts   int                          // Attempts, tries of a statement counting the first one, default 3
+	Backoff    time.Duration                // Backoff, wait after the first failure, doubled after every other one, default 50ms
+	MaxBackoff time.Duration                // MaxBackoff, longest wait between two attempts, default 1 second
+	OnRetry    func(attempt int, err error) // OnRetry, optional, called before every new attempt, like for logging
+}
+
+// WithRetry makes storm retry a SELECT that failed on a connection error (driver.ErrBadConn, a
+// reset or refused connection, a server shutting down), with exponential backoff capped at
+// policy.MaxBackoff, so a brief failover doesn't turn into errors for the callers:
+//
+//	db, err := storm.New("postgres", dsn, storm.WithRetry(storm.RetryPolicy{
+//		Attempts: 5,
+//		OnRetry:  func(attempt int, err error) { log.Printf("retrying select (%d): %v", attempt, err) },
+//	}))
+//
+// Only the statements starting with SELECT are retried, since running them twice is harmless,
+// and only when they fail before returning any row. The statements of a transaction are never
+// retried, a connection error ends the transaction. The wait stops when the context is done.
+func WithRetry(policy RetryPolicy) Option {
+	return func(s *Storm) {
+		if policy.Attempts <= 0 {
+			policy.Attempts = 3
+		}
+		if policy.Backoff <= 0 {
+			policy.Backoff = 50 * time.Millisecond
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = time.Second
+		}
+		s.retry = &policy
+	}
+}
+
+// queryRetry runs query like QueryContext, retrying it as configured by WithRetry when it is a
+// SELECT outside a transaction. The error of the last attempt is returned.
+func (s *Storm) queryRetry(query string, args []interface{}) (*sql.Rows, error) {
+	rows, err := s.conn.QueryContext(s.ctx, query, args...)
+	if err == nil || s.retry == nil || s.inTx() || !isSelect(query) {
+		return rows, err
+	}
+
+	backoff := s.retry.Backoff
+	for attempt := 2; attempt <= s.retry.Attempts && isTransient(err); attempt++ {
+		if s.retry.OnRetry != nil {
+			s.retry.OnRetry(attempt, err)
+		}
+		select {
+		case <-s.ctx.Done():
+			return nil, err
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, s.retry.MaxBackoff)
+
+		rows, err = s.conn.QueryContext(s.ctx, query, args...)
+		if err == nil {
+			return rows, nil
+		}
+	}
+	return nil, err
+}
+
+// isSelect reports whether query is a plain SELECT, a WITH can hide a write in its CTEs.
+func isSelect(query string) bool {
+	words := strings.Fields(query)
+	return len(words) > 0 && strings.EqualFold(words[0], "SELECT")
+}
+
+// isTransient reports whether err is a connection error that a new attempt, on another
+// connection, can succeed after.
+func isTransient(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+
+	// class 08 is connection exception, 57P01 to 57P03 a server shutting down or starting
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		code := string(pqErr.Code)
+		return strings.HasPrefix(code, "08") || code == "57P01" || code == "57P02" || code == "57P03"
+	}
+
+	// the other drivers are not dependencies of storm, so we recognize their messages
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset by peer") || strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "invalid connection")
+}
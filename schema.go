@@ -0,0 +1,328 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// Schema is the parsed metadata of a model struct, like its table name and
+// how every field map to a column. Storm parse it once per type and cache it,
+// it is exported for tools and test helpers that need to understand models like storm does.
+type Schema struct {
+	Type   reflect.Type
+	Table  string
+	Fields []*SchemaField
+	PK     *SchemaField // PK, the field tagged with `storm:"pk"`, nil if the model has none
+
+	Polymorphic   *SchemaField // Polymorphic, the discriminator field tagged with `storm:"polymorphic"`, nil if the model has none
+	Discriminator string       // Discriminator, the value of Polymorphic of a subtype, see RegisterSubtypes, empty for the others
+
+	Relations []*Relation // Relations, the fields tagged with hasmany or belongsto, they are not columns, see Preload
+}
+
+// Relation is an association of a Schema, a field filled by Preload with the rows of another model
+// instead of a column.
+type Relation struct {
+	Name       string       // Go field name, like "Posts"
+	Index      int          // index of the field in the struct
+	Embedded   []int        // index path of the embedded structs holding the field, nil for a field of the model itself
+	Type       reflect.Type // Go type of the field, like []Post or *User
+	Kind       string       // Kind, "hasmany" or "belongsto"
+	ForeignKey string       // ForeignKey, column of the other model (hasmany) or of this one (belongsto) holding the referenced primary key
+}
+
+// SchemaField is the metadata of a single struct field of a Schema.
+type SchemaField struct {
+	Name     string            // Go field name, like "Email"
+	Column   string            // column name in the database, like "email_user"
+	Index    int               // index of the field in the struct
+	Embedded []int             // index path of the embedded structs holding the field, nil for a field of the model itself
+	PK       bool              // is this the primary key
+	Type     reflect.Type      // Go type of the field
+	Tag      map[string]string // parsed storm tag, like {"pk": "", "column": "id"}
+}
+
+// schemaCache parses models with a naming strategy and caches them by their reflect.Type.
+// It is shared by every session of a Storm instance.
+type schemaCache struct {
+	naming   NamingStrategy
+	models   sync.Map
+	subtypes sync.Map // subtypes, the subtype of a type registered with RegisterSubtypes
+}
+
+// newSchemaCache creates an empty cache for naming, nil means DefaultNaming.
+func newSchemaCache(naming NamingStrategy) *schemaCache {
+	if naming == nil {
+		naming = DefaultNaming{}
+	}
+	return &schemaCache{naming: naming}
+}
+
+// parseTag parses a storm struct tag like `pk;column:user_id` into key value pair,
+// keys without value (like pk) are stored with empty value.
+func parseTag(tag string) map[string]string {
+	settings := map[string]string{}
+	for _, part := range strings.Split(tag, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, ":")
+		settings[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return settings
+}
+
+// ParseSchema parses the metadata of model with naming (nil means DefaultNaming).
+// The result is not cached, use Storm.Schema inside an application.
+func ParseSchema(model interface{}, naming NamingStrategy) (*Schema, error) {
+	return newSchemaCache(naming).parseModel(model)
+}
+
+// Schema returns the metadata of model, as storm use it to build statements.
+func (s *Storm) Schema(model interface{}) (*Schema, error) {
+	return s.schema.parseModel(model)
+}
+
+// parseModel returns the metadata of model, model can be a struct, a pointer to struct,
+// or a (pointer to) slice of struct, in that case the element type is used.
+func (c *schemaCache) parseModel(model interface{}) (*Schema, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("storm: model must be a struct or pointer to struct, got %T", model)
+	}
+	return c.parseType(t), nil
+}
+
+// parseType returns the (cached) metadata of struct type t.
+func (c *schemaCache) parseType(t reflect.Type) *Schema {
+	if cached, ok := c.models.Load(t); ok {
+		return cached.(*Schema)
+	}
+
+	info := &Schema{
+		Type:  t,
+		Table: c.naming.TableName(t.Name()), // by default table name = struct name + s
+	}
+	c.parseFields(info, t, nil)
+
+	// the fields of the model win over the fields with the same name of an embedded struct,
+	// like Go promotes them
+	depth := map[string]int{}
+	for _, fi := range info.Fields {
+		if d, ok := depth[fi.Name]; !ok || len(fi.Embedded) < d {
+			depth[fi.Name] = len(fi.Embedded)
+		}
+	}
+	fields := info.Fields[:0]
+	for _, fi := range info.Fields {
+		if len(fi.Embedded) > depth[fi.Name] {
+			continue
+		}
+		fields = append(fields, fi)
+		if fi.PK {
+			info.PK = fi
+		}
+		if _, ok := fi.Tag["polymorphic"]; ok && info.Polymorphic == nil {
+			info.Polymorphic = fi
+		}
+	}
+	info.Fields = fields
+
+	// a subtype lives in the table of its base model
+	if sub, ok := c.subtypes.Load(t); ok {
+		info.Table = sub.(subtype).table
+		info.Discriminator = sub.(subtype).value
+	}
+
+	cached, _ := c.models.LoadOrStore(t, info)
+	return cached.(*Schema)
+}
+
+// autoIncrement reports whether the database generates the value of field when it is inserted
+// as zero, like a SERIAL or AUTO_INCREMENT column. It is tagged with autoincrement, and integer
+// primary keys are autoincrement unless tagged with autoincrement:false. Other primary keys,
+// like UUIDs, are supplied by the client and always inserted.
+func (f *SchemaField) autoIncrement() bool {
+	if v, ok := f.Tag["autoincrement"]; ok {
+		return v != "false"
+	}
+	return f.PK && isInteger(f.Type)
+}
+
+// parseFields adds the fields of struct type t to info, path is the index path of t in the
+// model when t is an embedded struct. Embedded structs, and pointers to struct, are flattened:
+// their fields are columns of the model.
+func (c *schemaCache) parseFields(info *Schema, t reflect.Type, path []int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		settings := parseTag(field.Tag.Get("storm"))
+		if embedded := embeddedStruct(field, settings); embedded != nil {
+			c.parseFields(info, embedded, append(append([]int(nil), path...), i))
+			continue
+		}
+
+		// an association is filled by Preload, it is not a column
+		if rel := c.parseRelation(info, field, settings, path, i); rel != nil {
+			info.Relations = append(info.Relations, rel)
+			continue
+		}
+
+		fi := &SchemaField{
+			Name:     field.Name,
+			Column:   c.naming.ColumnName(field.Name),
+			Index:    i,
+			Embedded: path,
+			Type:     field.Type,
+			Tag:      settings,
+		}
+		if col, ok := settings["column"]; ok && col != "" {
+			fi.Column = col
+		} else if col := settings["polymorphic"]; col != "" {
+			// polymorphic:type names the column of the discriminator
+			fi.Column = col
+		}
+		if _, ok := settings["pk"]; ok {
+			fi.PK = true
+		}
+		info.Fields = append(info.Fields, fi)
+	}
+}
+
+// parseRelation returns the association of field, tagged with hasmany or belongsto, nil for a
+// column. Without a value the foreign key is named after the model (UserID for hasmany in User)
+// or the field (AuthorID for belongsto on Author), like the columns.
+func (c *schemaCache) parseRelation(info *Schema, field reflect.StructField, settings map[string]string, path []int, i int) *Relation {
+	rel := &Relation{Name: field.Name, Index: i, Embedded: path, Type: field.Type}
+	if fk, ok := settings["hasmany"]; ok {
+		rel.Kind, rel.ForeignKey = "hasmany", fk
+		if fk == "" {
+			rel.ForeignKey = c.naming.ColumnName(info.Type.Name() + "ID")
+		}
+		return rel
+	}
+	if fk, ok := settings["belongsto"]; ok {
+		rel.Kind, rel.ForeignKey = "belongsto", fk
+		if fk == "" {
+			rel.ForeignKey = c.naming.ColumnName(field.Name + "ID")
+		}
+		return rel
+	}
+	return nil
+}
+
+// embeddedStruct returns the struct type of field when it is an embedded struct (or pointer
+// to struct) that is flattened into the model, nil otherwise. Embedded structs that are values
+// of their own, like time.Time or a sql.Scanner, and the ones tagged with a column are kept as a column.
+func embeddedStruct(field reflect.StructField, settings map[string]string) reflect.Type {
+	if !field.Anonymous {
+		return nil
+	}
+	if _, ok := settings["column"]; ok {
+		return nil
+	}
+	t := field.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType || reflect.PointerTo(t).Implements(scannerType) {
+		return nil
+	}
+	return t
+}
+
+// Value returns the value of field in model, the struct value of the model. A field of a nil
+// embedded pointer gives the zero value of the field, which can't be set.
+func (f *SchemaField) Value(model reflect.Value) reflect.Value {
+	for _, i := range f.Embedded {
+		model = model.Field(i)
+		if model.Kind() == reflect.Ptr {
+			if model.IsNil() {
+				return reflect.Zero(f.Type)
+			}
+			model = model.Elem()
+		}
+	}
+	return model.Field(f.Index)
+}
+
+// Alloc returns the value of field in model, like Value, allocating the nil embedded pointers
+// on the way, so it can be set. model must be addressable.
+func (f *SchemaField) Alloc(model reflect.Value) reflect.Value {
+	for _, i := range f.Embedded {
+		model = model.Field(i)
+		if model.Kind() == reflect.Ptr {
+			if model.IsNil() {
+				model.Set(reflect.New(model.Type().Elem()))
+			}
+			model = model.Elem()
+		}
+	}
+	return model.Field(f.Index)
+}
+
+// readOnly reports whether field is tagged with ->, it is read from the database but never
+// written, like a column computed by the database.
+func (f *SchemaField) readOnly() bool {
+	_, ok := f.Tag["->"]
+	return ok
+}
+
+// writeOnly reports whether field is tagged with <-, it is written to the database but never
+// read back, like a password hash.
+func (f *SchemaField) writeOnly() bool {
+	_, ok := f.Tag["<-"]
+	return ok
+}
+
+// hasWriteOnly reports whether any field of info is tagged with <-.
+func (info *Schema) hasWriteOnly() bool {
+	for _, field := range info.Fields {
+		if field.writeOnly() {
+			return true
+		}
+	}
+	return false
+}
+
+// relation returns the association of info in the Go field name, nil if there is none.
+func (info *Schema) relation(name string) *Relation {
+	for _, rel := range info.Relations {
+		if rel.Name == name {
+			return rel
+		}
+	}
+	return nil
+}
+
+// Alloc returns the value of rel in model, allocating the nil embedded pointers on the way like
+// SchemaField.Alloc, so it can be set. model must be addressable.
+func (rel *Relation) Alloc(model reflect.Value) reflect.Value {
+	return (&SchemaField{Index: rel.Index, Embedded: rel.Embedded}).Alloc(model)
+}
+
+// field returns the field of info stored in column, nil if there is none.
+func (info *Schema) field(column string) *SchemaField {
+	for _, field := range info.Fields {
+		if field.Column == column {
+			return field
+		}
+	}
+	return nil
+}
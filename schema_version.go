@@ -0,0 +1,66 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// migrationsTable is where the migrate package records the applied migrations.
+const migrationsTable = "storm_migrations"
+
+// RequireSchemaVersion makes New check that the database schema is at least at version, the
+// version of the last migration the application needs, and fail with a descriptive error
+// when it is older, instead of failing later on a missing column:
+//
+//	db, err := storm.New("postgres", dsn, storm.RequireSchemaVersion(20250301120000))
+//	// storm: database schema is at version 20250214093000, the application needs 20250301120000,
+//	// apply the pending migrations first (storm migrate up)
+//
+// The version is read from the storm_migrations table of the migrate package.
+// NewWithDB doesn't connect, so it ignores this option, call SchemaVersion instead.
+func RequireSchemaVersion(version int64) Option {
+	return func(s *Storm) {
+		s.requiredVersion = version
+	}
+}
+
+// SchemaVersion returns the version of the last migration applied to the database by the
+// migrate package, 0 when none was applied.
+func (s *Storm) SchemaVersion() (int64, error) {
+	var latest int64
+	q := fmt.Sprintf("SELECT version FROM %s", s.tableName(migrationsTable))
+	err := s.queryRows(q, nil, func(rows *sql.Rows) error {
+		for rows.Next() {
+			var version string
+			if err := rows.Scan(&version); err != nil {
+				return err
+			}
+			// versions are timestamps, a version that is not a number isn't one of migrate
+			if n, err := strconv.ParseInt(version, 10, 64); err == nil && n > latest {
+				latest = n
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("storm: read schema version from %s (were the migrations run?): %v", migrationsTable, err)
+	}
+	return latest, nil
+}
+
+// checkSchemaVersion returns an error when the database schema is older than the version
+// required with RequireSchemaVersion.
+func (s *Storm) checkSchemaVersion() error {
+	if s.requiredVersion == 0 {
+		return nil
+	}
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if version < s.requiredVersion {
+		return fmt.Errorf("storm: database schema is at version %d, the application needs %d, apply the pending migrations first (storm migrate up)", version, s.requiredVersion)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package storm
+
+import (
+	"context"
+	"sync"
+)
+
+// Scope returns the condition a global scope adds to the statements on table, the table as it
+// appears in the statement. Return nil to add nothing, for example for tables without tenant.
+// ctx is the context of the session, where request values like the tenant usually are.
+type Scope func(ctx context.Context, table string) Expr
+
+// scopeRegistry, keep the global scopes in the order they were registered
+type scopeRegistry struct {
+	mu     sync.RWMutex
+	names  []string
+	scopes map[string]Scope
+}
+
+// RegisterScope registers a global scope under name, its condition is added to every SELECT,
+// UPDATE and DELETE storm builds, registering a name again replaces the scope.
+// Every session shares the scopes, use Unscoped to bypass them:
+//
+//	db.RegisterScope("tenant", func(ctx context.Context, table string) storm.Expr {
+//		if table == "audit_logs" {
+//			return nil
+//		}
+//		return storm.Eq{"tenant_id": ctx.Value(tenantKey)}
+//	})
+//	err := db.WithContext(ctx).From(&User{}).Select(&users) // ... WHERE tenant_id = $1
+func (s *Storm) RegisterScope(name string, scope Scope) {
+	s.scopes.mu.Lock()
+	defer s.scopes.mu.Unlock()
+
+	if s.scopes.scopes == nil {
+		s.scopes.scopes = map[string]Scope{}
+	}
+	if _, ok := s.scopes.scopes[name]; !ok {
+		s.scopes.names = append(s.scopes.names, name)
+	}
+	s.scopes.scopes[name] = scope
+}
+
+// Unscoped returns a session that ignores the global scopes, for example for an admin
+// report across every tenant: db.Unscoped().From(&User{}).Select(&users)
+// It also sees the rows of SoftDeletable models marked as deleted, and Delete removes them for real.
+func (s *Storm) Unscoped() *Storm {
+	session := s.Session(nil)
+	session.unscoped = true
+	return session
+}
+
+// scoped returns where with the conditions of the global scopes of table added after it,
+// where itself is never changed.
+func (s *Storm) scoped(table string, where whereClause) whereClause {
+	if s.unscoped {
+		return where
+	}
+
+	s.scopes.mu.RLock()
+	defer s.scopes.mu.RUnlock()
+
+	result := where[:len(where):len(where)]
+	for _, name := range s.scopes.names {
+		if expr := s.scopes.scopes[name](s.ctx, table); expr != nil {
+			result = append(result, expr)
+		}
+	}
+	return result
+}
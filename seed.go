@@ -0,0 +1,69 @@
+package storm
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// seedsTable is where storm records the seeders that already ran.
+const seedsTable = "storm_seeds"
+
+// Seeder populates the database with data from Go code, for development and staging environments.
+// Name identifies the seeder, once it ran successfully it is recorded and never run again.
+type Seeder struct {
+	Name string
+	Run  func(tx *Tx) error
+}
+
+// Seed runs seeders in order, skipping those that already ran. Each seeder runs in its own
+// transaction together with its record in the storm_seeds table, so a failing seeder leaves
+// nothing behind and is retried on the next call.
+//
+//	err := db.Seed(
+//		storm.Seeder{Name: "admin_user", Run: func(tx *storm.Tx) error {
+//			return tx.Insert(&models.User{Name: "admin", Email: "admin@example.com"})
+//		}},
+//	)
+func (s *Storm) Seed(seeders ...Seeder) error {
+	_, err := s.exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (name VARCHAR(255) PRIMARY KEY, seeded_at TIMESTAMP NOT NULL)", s.tableName(seedsTable)))
+	if err != nil {
+		return fmt.Errorf("storm: create %s table: %v", seedsTable, err)
+	}
+
+	for _, seeder := range seeders {
+		if seeder.Name == "" || seeder.Run == nil {
+			return fmt.Errorf("storm: seeder needs a name and a run function")
+		}
+
+		seeded, err := s.seeded(seeder.Name)
+		if err != nil {
+			return err
+		}
+		if seeded {
+			continue
+		}
+
+		err = s.Transaction(func(tx *Tx) error {
+			if err := seeder.Run(tx); err != nil {
+				return err
+			}
+			_, err := tx.exec(fmt.Sprintf("INSERT INTO %s (name, seeded_at) VALUES ($1, $2)", tx.tableName(seedsTable)), seeder.Name, time.Now())
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("storm: seeder %s: %w", seeder.Name, err)
+		}
+	}
+	return nil
+}
+
+// seeded reports whether the seeder with name already ran.
+func (s *Storm) seeded(name string) (bool, error) {
+	found := false
+	err := s.queryRows(fmt.Sprintf("SELECT 1 FROM %s WHERE name = $1", s.tableName(seedsTable)), []interface{}{name}, func(rows *sql.Rows) error {
+		found = rows.Next()
+		return nil
+	})
+	return found, err
+}
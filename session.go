@@ -0,0 +1,143 @@
+package storm
+
+import (
+	"context"
+	"strings"
+)
+
+// SessionConfig is the per-session configuration given to Session.
+// Zero values keep the setting of the handle the session is created from.
+type SessionConfig struct {
+	Logger      Logger            // Logger, receive every statement of the session
+	DryRun      bool              // DryRun, build and log statements without sending them to the database
+	Context     context.Context   // Context, used for every statement of the session
+	SkipHooks   bool              // SkipHooks, don't call the model hooks (BeforeInsert, AfterFind, ...)
+	Schema      string            // Schema, database schema every table is qualified with, see WithSchema
+	ReadOnly    bool              // ReadOnly, refuse every statement that writes, see ReadOnly
+	Mask        bool              // Mask, mask the fields tagged with mask when reading rows, see Masked
+	Tags        map[string]string // Tags, attached to the QueryEvent of every statement, added to the ones of the handle
+	IdentityMap bool              // IdentityMap, give the session its own identity map, see WithIdentityMap
+}
+
+// Session returns a new independent handle with config applied on top of the settings of s.
+// It shares the connection pool and registries with s, but changing the session never
+// mutates s, so request-scoped settings stay in the request:
+//
+//	tx := db.Session(&storm.SessionConfig{Context: r.Context(), Logger: requestLogger})
+//	err := tx.Insert(&user)
+func (s *Storm) Session(config *SessionConfig) *Storm {
+	session := *s
+	if config == nil {
+		return &session
+	}
+
+	if config.Logger != nil {
+		session.logger = config.Logger
+	}
+	if config.Context != nil {
+		session.ctx = config.Context
+	}
+	if config.DryRun {
+		session.dryRun = true
+		// a new dry run session records its own statements, see Statements
+		if !s.dryRun {
+			session.recorded = &[]Statement{}
+		}
+	}
+	if config.SkipHooks {
+		session.skipHooks = true
+	}
+	if config.Schema != "" {
+		session.dbSchema = config.Schema
+	}
+	if config.ReadOnly {
+		session.readOnly = true
+	}
+	if config.Mask {
+		session.masked = true
+	}
+	if len(config.Tags) > 0 {
+		// the tags are copied, so the ones of s never change
+		tags := make(map[string]string, len(s.tags)+len(config.Tags))
+		for k, v := range s.tags {
+			tags[k] = v
+		}
+		for k, v := range config.Tags {
+			tags[k] = v
+		}
+		session.tags = tags
+	}
+	if config.IdentityMap {
+		session.identity = &identityMap{models: map[identityKey]interface{}{}}
+	}
+	return &session
+}
+
+// WithContext returns a session that use ctx for every statement,
+// a shortcut of Session(&SessionConfig{Context: ctx}).
+func (s *Storm) WithContext(ctx context.Context) *Storm {
+	return s.Session(&SessionConfig{Context: ctx})
+}
+
+// WithSchema returns a session whose tables live in the database schema name, every table name
+// storm generates is qualified with it (tenant_42.users), for schema-per-tenant multi-tenancy:
+//
+//	tenantDB := db.WithSchema("tenant_42")
+//	err := tenantDB.From(&User{}).Select(&users) // SELECT * FROM tenant_42.users
+//
+// Qualifying the tables, instead of setting search_path, is safe with a connection pool since
+// no state is left on the connections. Names that are already qualified are kept as they are.
+func (s *Storm) WithSchema(name string) *Storm {
+	return s.Session(&SessionConfig{Schema: name})
+}
+
+// tableName qualifies table with the database schema of the session, if there is one.
+func (s *Storm) tableName(table string) string {
+	if s.dbSchema == "" || strings.Contains(table, ".") {
+		return table
+	}
+	return s.dbSchema + "." + table
+}
+
+// Statement is a SQL statement with its arguments, exactly as storm sends it to the database.
+type Statement struct {
+	SQL  string
+	Args []interface{}
+}
+
+// DryRun returns a session that builds the statements without sending them to the database,
+// a shortcut of Session(&SessionConfig{DryRun: true}). Insert, Update and Delete still run the
+// Before hooks, and their statements can be read with Statements, for previews and tests:
+//
+//	preview := db.DryRun()
+//	err := preview.Insert(&user)
+//	stmt := preview.Statements()[0] // INSERT INTO users (name_user, email_user) VALUES ($1, $2) [aji aji@handsome.com]
+//
+// Reads return no rows. The After hooks are not called, nothing happened.
+func (s *Storm) DryRun() *Storm {
+	return s.Session(&SessionConfig{DryRun: true})
+}
+
+// Statements returns the statements built so far by a dry run session (and the queries and
+// sessions created from it), in order, nil outside dry run.
+func (s *Storm) Statements() []Statement {
+	if !s.dryRun || s.recorded == nil {
+		return nil
+	}
+	return append([]Statement(nil), *s.recorded...)
+}
+
+// ToSQL runs fn with a dry run session of s and returns every statement fn would have
+// executed, in order, without touching the database. Reads inside fn return no rows.
+//
+//	stmts, err := db.ToSQL(func(tx *storm.Storm) error {
+//		return tx.Insert(&user)
+//	})
+//	// stmts[0].SQL == "INSERT INTO users (name_user, email_user) VALUES ($1, $2)"
+func (s *Storm) ToSQL(fn func(tx *Storm) error) ([]Statement, error) {
+	session := s.Session(&SessionConfig{DryRun: true})
+	session.recorded = &[]Statement{}
+
+	err := fn(session)
+	return *session.recorded, err
+}
@@ -0,0 +1,98 @@
+package storm
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SoftDeletable is implemented by the models that are marked as deleted instead of being
+// removed from their table. Delete runs an UPDATE with the columns of SoftDelete, and the
+// queries started from the model (From, Get, Paginate, ...) only see the rows NotDeleted
+// matches, so the deletion marker can be anything the project already uses:
+//
+//	type Post struct {
+//		ID       int `storm:"pk"`
+//		Archived bool
+//	}
+//
+//	func (Post) SoftDelete() map[string]interface{} { return map[string]interface{}{"archived": true} }
+//	func (Post) NotDeleted() storm.Expr            { return storm.Eq{"archived": false} }
+//
+// Unscoped sees the deleted rows and deletes for real, WithDeleted sees them in one query.
+// Embed SoftDeleteAt for the usual deleted_at column.
+type SoftDeletable interface {
+	SoftDelete() map[string]interface{} // SoftDelete, the columns set to mark a row as deleted
+	NotDeleted() Expr                   // NotDeleted, the condition of the rows that are not deleted
+}
+
+// SoftDeleteAt is the deleted_at convention of SoftDeletable, embed it in a model:
+//
+//	type User struct {
+//		ID   int `storm:"pk"`
+//		Name string
+//		storm.SoftDeleteAt
+//	}
+//
+// DeletedAt is the time the row was deleted, NULL while it is not.
+type SoftDeleteAt struct {
+	DeletedAt *time.Time `storm:"column:deleted_at"`
+}
+
+// SoftDelete sets deleted_at to now.
+func (SoftDeleteAt) SoftDelete() map[string]interface{} {
+	return map[string]interface{}{"deleted_at": time.Now()}
+}
+
+// NotDeleted matches the rows whose deleted_at is NULL.
+func (SoftDeleteAt) NotDeleted() Expr {
+	return Eq{"deleted_at": nil}
+}
+
+// WithDeleted makes the query see the rows of a SoftDeletable model that are marked as
+// deleted, for example to restore them. The global scopes still apply, see Unscoped.
+func (q *Query) WithDeleted() *Query {
+	q.withDeleted = true
+	return q
+}
+
+// conditions returns where with the condition of the not deleted rows, when the model of the
+// query is SoftDeletable, the condition of the rows of a subtype (see RegisterSubtypes), and the
+// global scopes added after them.
+func (q *Query) conditions(where whereClause) whereClause {
+	if expr := q.storm.discriminatorExpr(q.model); expr != nil {
+		where = append(where[:len(where):len(where)], expr)
+	}
+	if soft, ok := q.model.(SoftDeletable); ok && !q.withDeleted && !q.storm.unscoped {
+		if expr := soft.NotDeleted(); expr != nil {
+			where = append(where[:len(where):len(where)], expr)
+		}
+	}
+	return q.storm.scoped(q.table, where)
+}
+
+// softDelete builds the UPDATE marking the rows of table matched by where as deleted,
+// with the columns of soft.
+func (s *Storm) softDelete(soft SoftDeletable, table string, where whereClause) (string, []interface{}, error) {
+	marker := soft.SoftDelete()
+	if len(marker) == 0 {
+		return "", nil, fmt.Errorf("storm: SoftDelete of %s returns no column", table)
+	}
+
+	set := &params{}
+	assignments := make([]string, 0, len(marker))
+	for _, col := range sortedKeys(marker) {
+		assignments = append(assignments, fmt.Sprintf("%s = %s", col, set.add(marker[col])))
+	}
+
+	// a row already deleted is left as it is, so its deletion time doesn't move
+	if expr := soft.NotDeleted(); expr != nil {
+		where = append(where[:len(where):len(where)], expr)
+	}
+	cond, args, err := s.scoped(table, where).build(len(set.args))
+	if err != nil {
+		return "", nil, err
+	}
+	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(assignments, ", "), cond)
+	return q, append(set.args, args...), nil
+}
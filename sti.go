@@ -0,0 +1,112 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// subtype, the table and the discriminator value of a subtype registered with RegisterSubtypes
+type subtype struct {
+	table string
+	value string
+}
+
+// RegisterSubtypes registers the subtypes of base, a model with a discriminator field tagged
+// with polymorphic, for single-table inheritance: every subtype embeds base and lives in its
+// table, told apart by the value of the discriminator column:
+//
+//	type Animal struct {
+//		ID   int    `storm:"pk"`
+//		Kind string `storm:"polymorphic:kind"` // the column kind is the discriminator
+//		Name string
+//	}
+//
+//	type Dog struct {
+//		Animal
+//		Barks bool
+//	}
+//
+//	err := db.RegisterSubtypes(&Animal{}, map[string]interface{}{"dog": &Dog{}, "cat": &Cat{}})
+//
+// Then Insert(&Dog{}) writes kind = 'dog' into animals, the queries started from &Dog{} only see
+// the rows of dogs, and the queries started from &Animal{} fill a slice of an interface (or of
+// interface{}) with a *Dog or a *Cat for every row:
+//
+//	var pets []interface{}
+//	err = db.From(&Animal{}).Select(&pets)
+//
+// Register the subtypes before using them, at startup.
+func (s *Storm) RegisterSubtypes(base interface{}, subtypes map[string]interface{}) error {
+	baseType := reflect.TypeOf(base)
+	if baseType != nil && baseType.Kind() == reflect.Ptr {
+		baseType = baseType.Elem()
+	}
+	if baseType == nil || baseType.Kind() != reflect.Struct {
+		return fmt.Errorf("storm: RegisterSubtypes base must be a struct or a pointer to a struct, got %s", describe(base))
+	}
+	info := s.schema.parseType(baseType)
+	if info.Polymorphic == nil {
+		return fmt.Errorf("storm: RegisterSubtypes base %s has no field tagged with polymorphic", baseType.Name())
+	}
+	if info.Polymorphic.Type.Kind() != reflect.String {
+		return fmt.Errorf("storm: the polymorphic field %s.%s must be a string, not %s", baseType.Name(), info.Polymorphic.Name, info.Polymorphic.Type)
+	}
+
+	poly := &polymorphic{column: info.Polymorphic.Column, types: make(map[string]concreteType, len(subtypes))}
+	for value, model := range subtypes {
+		t := reflect.TypeOf(model)
+		if t != nil && t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t == nil || t.Kind() != reflect.Struct || !embeds(t, baseType) {
+			return fmt.Errorf("storm: RegisterSubtypes subtype %q must be a struct embedding %s, got %s", value, baseType.Name(), describe(model))
+		}
+		poly.types[value] = concreteType{typ: t, ptr: true}
+	}
+
+	for value, concrete := range poly.types {
+		s.schema.subtypes.Store(concrete.typ, subtype{table: info.Table, value: value})
+		// the subtype is parsed again with the table of base
+		s.schema.models.Delete(concrete.typ)
+	}
+
+	s.types.mu.Lock()
+	defer s.types.mu.Unlock()
+	if s.types.bases == nil {
+		s.types.bases = map[reflect.Type]*polymorphic{}
+	}
+	s.types.bases[baseType] = poly
+	return nil
+}
+
+// embeds reports whether struct type t embeds base, or a pointer to base.
+func embeds(t, base reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && (field.Type == base || field.Type == reflect.PointerTo(base)) {
+			return true
+		}
+	}
+	return false
+}
+
+// setDiscriminator sets the discriminator of val, a model of info, when it is a subtype.
+func setDiscriminator(info *Schema, val reflect.Value) {
+	if info.Discriminator == "" {
+		return
+	}
+	info.Polymorphic.Alloc(val).SetString(info.Discriminator)
+}
+
+// discriminatorExpr returns the condition of the rows of the subtype model, nil when model
+// is not a subtype.
+func (s *Storm) discriminatorExpr(model interface{}) Expr {
+	if model == nil {
+		return nil
+	}
+	info := s.schema.parseType(reflect.TypeOf(model).Elem())
+	if info.Discriminator == "" {
+		return nil
+	}
+	return Eq{info.Polymorphic.Column: info.Discriminator}
+}
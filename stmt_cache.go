@@ -0,0 +1,92 @@
+package storm
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// maxTxStmts is how many statements a transaction keeps prepared, the statements seen after
+// that are executed without preparing, so a transaction with endless different statements
+// doesn't fill the server with them.
+const maxTxStmts = 100
+
+// txConn is the executor of a transaction. A statement executed a second time in the
+// transaction is prepared, and the prepared statement is reused from then on, so a loop
+// inserting thousands of rows is parsed and planned once. Statements executed once are not
+// prepared, that would cost them a round trip, neither are the statements without arguments:
+// their values are in the SQL, like the statements of a Batch joined into one string, which
+// postgres can't prepare, so they are rarely the same twice. The prepared statements are
+// closed by database/sql when the transaction ends.
+type txConn struct {
+	tx *sql.Tx
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt // stmts, by query, nil for a query seen once
+}
+
+// newTxConn returns the executor of tx.
+func newTxConn(tx *sql.Tx) *txConn {
+	return &txConn{tx: tx, stmts: map[string]*sql.Stmt{}}
+}
+
+// ExecContext implements executor.
+func (c *txConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if len(args) == 0 {
+		return c.tx.ExecContext(ctx, query)
+	}
+	stmt, err := c.prepared(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return c.tx.ExecContext(ctx, query, args...)
+	}
+	return stmt.ExecContext(ctx, args...)
+}
+
+// QueryContext implements executor.
+func (c *txConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if len(args) == 0 {
+		return c.tx.QueryContext(ctx, query)
+	}
+	stmt, err := c.prepared(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if stmt == nil {
+		return c.tx.QueryContext(ctx, query, args...)
+	}
+	return stmt.QueryContext(ctx, args...)
+}
+
+// prepared returns the prepared statement of query, preparing it when query is seen for the
+// second time, or nil when query is to be executed as it is.
+func (c *txConn) prepared(ctx context.Context, query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stmt, seen := c.stmts[query]
+	if stmt != nil {
+		return stmt, nil
+	}
+	if !seen {
+		if len(c.stmts) < maxTxStmts {
+			c.stmts[query] = nil
+		}
+		return nil, nil
+	}
+
+	stmt, err := c.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// inTx reports whether the statements of s run in a transaction.
+func (s *Storm) inTx() bool {
+	_, ok := s.conn.(*txConn)
+	return ok
+}
@@ -0,0 +1,90 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Store is the set of model operations Storm provides. Depend on Store instead of *Storm
+// in your repositories, so they can be unit tested with the in-memory stormtest.Fake
+// without a database.
+type Store interface {
+	Insert(model interface{}) error
+	Update(model interface{}) (int64, error)
+	Delete(model interface{}) (int64, error)
+	Get(dest interface{}, id interface{}) error
+	FindAll(dest interface{}, conditions interface{}) error
+}
+
+// Storm must always satisfy Store
+var _ Store = (*Storm)(nil)
+
+// Get loads the row whose primary key equals id into dest, a pointer to model struct.
+// It returns ErrRecordNotFound if there is no such row. With WithCache, it reads through the cache.
+// Example: var user User; err := db.Get(&user, 42)
+func (s *Storm) Get(dest interface{}, id interface{}) error {
+	if err := checkStruct("dest", dest); err != nil {
+		return err
+	}
+	info, err := s.schema.parseModel(dest)
+	if err != nil {
+		return err
+	}
+	if info.PK == nil {
+		return fmt.Errorf("storm: %s has no primary key", info.Type.Name())
+	}
+
+	// in a session with an identity map, a row is only loaded once
+	var key identityKey
+	if s.identity != nil {
+		key = s.identityKey(info.Type, id)
+		if model, ok := s.identity.get(key); ok {
+			reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(model).Elem())
+			return nil
+		}
+	}
+
+	table := s.tableName(info.Table)
+	// models with encrypted fields are not cached, their plain text stays in the application,
+	// and masked models are not the real rows
+	cached := s.useCache() && !info.hasEncrypted() && !s.masked && s.unfiltered(dest)
+	var cachedKey string
+	if cached {
+		cachedKey = s.cacheKey(table, id)
+	}
+	if !cached || !s.cachedGet(cachedKey, dest) {
+		// the row is cached as the database returned it, before the AfterFind hooks change it
+		found, err := s.From(dest).Where(info.PK.Column+" = $1", id).scanFirst(dest)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return ErrRecordNotFound
+		}
+		if cached {
+			s.cacheSet(cachedKey, dest)
+		}
+	}
+	if err := s.callHook(HookAfterFind, dest); err != nil {
+		return err
+	}
+	if s.identity != nil {
+		s.identity.put(key, dest)
+	}
+	return nil
+}
+
+// FindAll loads every row matching conditions into dest, a pointer to slice of model.
+// conditions is anything Where accepts (a map, a partially filled model or an Expr),
+// nil loads every row.
+// Example: var users []User; err := db.FindAll(&users, map[string]interface{}{"status": "active"})
+func (s *Storm) FindAll(dest interface{}, conditions interface{}) error {
+	if err := checkSlice(dest); err != nil {
+		return err
+	}
+	q := s.From(reflect.New(reflect.TypeOf(dest).Elem().Elem()).Interface())
+	if conditions != nil {
+		q.Where(conditions)
+	}
+	return q.Select(dest)
+}
@@ -1,34 +1,129 @@
 package storm
 
 import (
+	"context"
+	"crypto/cipher"
 	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // Storm is the main ORM struct that wraps a *sql.DB connection.
 // It provides methods to perform basic CRUD operations (Insert, Update, Delete)
 // and query building (via Query).
 type Storm struct {
-	db *sql.DB
+	db              *sql.DB
+	conn            executor // conn, where statements run: db itself, or the txConn of a transaction
+	dsn             string   // dsn given to New, empty with NewWithDB, used by Listen for its own connection
+	dialect         Dialect
+	named           *namedQueries                              // registry of named queries, see RegisterQuery
+	scopes          *scopeRegistry                             // global scopes, see RegisterScope
+	callbacks       *callbackRegistry                          // plugin callbacks, see Use and RegisterCallback
+	types           *typeRegistry                              // types, the concrete types of interfaces, see RegisterTypes
+	events          *eventBus                                  // change listeners, see OnChange
+	cache           *modelCache                                // second-level cache of models, nil without WithCache
+	aead            cipher.AEAD                                // key of the fields tagged encrypt, see WithEncryption
+	beginHooks      []func(tx *Tx) error                       // run at the start of every transaction, see OnBegin
+	schema          *schemaCache                               // parsed model metadata, with the naming strategy
+	stats           *queryStats                                // statement and error counters, see Health
+	plans           *planStats                                 // plans, the SELECT statements counted for AnalyzePlans
+	matcher         ColumnMatcher                              // matcher, match columns with fields beyond exact names, see WithColumnMatcher
+	connectRetry    *ConnectRetry                              // connectRetry, how New retries its ping, nil pings once, see WithConnectRetry
+	pool            ConnPool                                   // pool, settings of the connection pool, see WithConnPool
+	connectHooks    []func(ctx context.Context, c *Conn) error // run on every new connection, see OnConnect
+	disconnectHooks []func(c *Conn)                            // run before a connection is closed, see OnDisconnect
+	location        *time.Location                             // location, of the times read and written, nil keeps them as they are, see WithTimeZone
+	requiredVersion int64                                      // requiredVersion, oldest schema version New accepts, 0 accepts any, see RequireSchemaVersion
+	retry           *RetryPolicy                               // retry, how the SELECTs are retried on connection errors, nil never, see WithRetry
+	preloadWorkers  int                                        // preloadWorkers, associations Preload loads at the same time, 0 is the default, see WithPreloadConcurrency
+
+	// below are the session settings, every Session gets its own copy of them
+	ctx        context.Context   // context used for every statement, see WithContext
+	logger     Logger            // logger receive every statement, nil means no logging
+	dryRun     bool              // dryRun, build and log statements without sending them
+	recorded   *[]Statement      // recorded, statements built in dry run, used by ToSQL
+	skipHooks  bool              // skipHooks, don't call the model hooks
+	dbSchema   string            // dbSchema, database schema table names are qualified with, see WithSchema
+	unscoped   bool              // unscoped, ignore the global scopes and the soft deletes, see Unscoped
+	readOnly   bool              // readOnly, refuse every statement that writes, see ReadOnly
+	readOnlyTx bool              // readOnlyTx, the transaction of the session was started READ ONLY
+	masked     bool              // masked, mask the fields tagged with mask when reading, see Masked
+	tags       map[string]string // tags, attached to the QueryEvent of every statement, see Query.Tag
+	identity   *identityMap      // identity, the rows loaded by primary key, see WithIdentityMap
+	write      *writeOp          // write, the Update or Delete whose hooks and callbacks are running, see RowsAffected
+
+	pendingChanges *[]ChangeEvent // pendingChanges, change events of a transaction, emitted on commit
 }
 
 // New creates a new Storm instance by opening a database connection using
 // the provided driverName (e.g., "postgres", "mysql") and dsn (data source name).
 // It verifies the connection with Ping and returns a Storm instance or an error.
-func New(driverName, dsn string) (*Storm, error) {
+// Options like WithLogger or WithConnPool configure the instance declaratively.
+func New(driverName, dsn string, opts ...Option) (*Storm, error) {
 	db, err := sql.Open(driverName, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("Failed to open database connection: %v", err)
 	}
 
-	err = db.Ping()
+	s := newStorm(db, driverName, opts)
+	s.dsn = dsn
+
+	// the connection hooks need their own connector, so the pool is opened again with it
+	if len(s.connectHooks) > 0 || len(s.disconnectHooks) > 0 {
+		connector, err := s.hookConnector(db.Driver(), dsn)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to open database connection: %v", err)
+		}
+		db.Close()
+		s.db = sql.OpenDB(connector)
+		s.conn = s.db
+		s.applyPool()
+	}
+
+	err = s.ping()
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %v", err)
 	}
+	if err := s.checkSchemaVersion(); err != nil {
+		s.db.Close()
+		return nil, err
+	}
 
-	return &Storm{db}, nil
+	return s, nil
+}
+
+// NewWithDB creates a Storm instance on top of a *sql.DB the application already manages,
+// for example one opened with an instrumented driver. dialect is the name of the database,
+// like "postgres" or "mysql", used to pick the SQL dialect.
+// Unlike New, it doesn't ping db, the application is in charge of the connection.
+func NewWithDB(db *sql.DB, dialect string, opts ...Option) *Storm {
+	return newStorm(db, dialect, opts)
+}
+
+// newStorm creates the Storm instance for db and applies opts.
+func newStorm(db *sql.DB, dialect string, opts []Option) *Storm {
+	s := &Storm{
+		db:        db,
+		conn:      db,
+		dialect:   dialectFor(dialect),
+		named:     &namedQueries{},
+		scopes:    &scopeRegistry{},
+		callbacks: &callbackRegistry{},
+		types:     &typeRegistry{},
+		events:    &eventBus{},
+		stats:     &queryStats{},
+		plans:     &planStats{},
+		matcher:   DefaultColumnMatcher,
+		schema:    newSchemaCache(nil),
+		ctx:       context.Background(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.applyPool()
+	return s
 }
 
 // DB returns the underlying *sql.DB instance so you can execute raw queries if needed.
@@ -36,159 +131,321 @@ func (s *Storm) DB() *sql.DB {
 	return s.db
 }
 
+// Close closes the underlying database connection pool.
+// Every session created from this instance stops working too, since they share the pool.
+func (s *Storm) Close() error {
+	return s.db.Close()
+}
+
+// PingContext verifies the connection to the database is still alive.
+func (s *Storm) PingContext(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Stats returns the statistics of the connection pool, like open and in use connections.
+func (s *Storm) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Context returns the context used for the statements of this handle.
+func (s *Storm) Context() context.Context {
+	return s.ctx
+}
+
+// Dialect returns the SQL dialect storm use to generate statements for this database.
+func (s *Storm) Dialect() Dialect {
+	return s.dialect
+}
+
 // Insert inserts a struct record into the database.
 // It uses reflection to read struct tags (`storm:"column:..."`) and build
 // the appropriate SQL INSERT statement.
 func (s *Storm) Insert(model interface{}) error {
+	if err := checkStruct("model", model); err != nil {
+		return err
+	}
+	if err := s.callHook(HookBeforeInsert, model); err != nil {
+		return err
+	}
+
 	// val, its reflect the value of the struct that we passes
 	val := reflect.ValueOf(model).Elem()
-	// tipe, its reflect the datatype of this struct above
-	tipe := val.Type()
+	// info, is the parsed metadata of the struct, its table and the column of every field
+	info := s.schema.parseType(val.Type())
+	setDiscriminator(info, val)
+	if err := validate(info, val, false); err != nil {
+		return err
+	}
 
 	// columns, its all column that we need to insert represent the struct
 	var columns []string
 	// placeholders, is for value placeholder to insert the column
 	var placeholders []string
-	// values, is the values of column we want to insert
-	var values []interface{}
-
-	col := ""
-
-	// below we loop the number of field in the struct
-	for i := 0; i < val.NumField(); i++ {
-		// field, we get the field of the struct, like name of struct, tag etc
-		field := tipe.Field(i)
-		// tag, we get the tag of struct like when we describe for example `json:""` in this below, we get the `storm:name` tag
-		tag := field.Tag.Get("storm")
-
-		// if the field is primary_key, then we skip that
-		is_primary := strings.Contains(tag, "pk")
-		is_column := strings.Contains(tag, "column")
-		if is_primary {
+	// args, hold the values of column we want to insert and number the placeholder sequentially
+	args := newParams()
+
+	// below we loop the fields of the struct, the column name already resolved from the
+	// `storm:"column:..."` tag or the naming strategy
+	for _, field := range info.Fields {
+		fieldVal := field.Value(val)
+		// if the field is auto increment and zero, the database generates it, so we skip that,
+		// like the fields we never write. A primary key supplied by the client is inserted
+		if (field.autoIncrement() && fieldVal.IsZero()) || !field.writable() {
 			continue
 		}
 
-		// if in the tag we using column tag, for specify column name, then we use that to insert
-		if is_column {
-			col = strings.Split(tag, ":")[1]
-		} else {
-			// otheriwise we use, the field name
-			col = strings.ToLower(field.Name)
+		value, err := s.writeValue(field, fieldVal)
+		if err != nil {
+			return err
 		}
-
-		placeHolderVal := fmt.Sprintf("$%d", i)
-
-		columns = append(columns, col)
-		placeholders = append(placeholders, placeHolderVal)
-		values = append(values, val.Field(i).Interface())
+		columns = append(columns, field.Column)
+		placeholders = append(placeholders, args.add(value))
 	}
 
 	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
-		strings.ToLower(tipe.Name()+"s"), // table name = struct name
+		s.tableName(info.Table),
 		strings.Join(columns, ", "),
 		strings.Join(placeholders, ", "),
 	)
 
-	_, err := s.db.Exec(q, values...)
+	// generated columns are read back from the inserted row
+	if generated := info.generatedFields(); len(generated) > 0 {
+		if _, err := s.execReturning(q, args.args, []reflect.Value{val}, generated); err != nil {
+			return err
+		}
+	} else if _, err := s.exec(q, args.args...); err != nil {
+		return err
+	}
 
-	return err
+	return s.callHook(HookAfterInsert, model)
 }
 
 // Update updates an existing struct record in the database based on its primary key.
 // It reads `storm` struct tags and generates a dynamic SQL UPDATE statement.
 // Only non-zero fields will be updated.
-func (s *Storm) Update(model interface{}) error {
+// It returns the number of rows affected, so an update of a missing row can be detected (0 rows).
+//
+// A model with an integer field tagged `storm:"version"` is updated with optimistic locking:
+// the row is only updated if its version is still the one of the model, and the version is
+// incremented. Otherwise Update returns ErrStaleObject (also when the row was deleted), see RetryOnConflict.
+func (s *Storm) Update(model interface{}) (int64, error) {
+	if err := checkStruct("model", model); err != nil {
+		return 0, err
+	}
+	s = s.startWrite()
+	if err := s.callHook(HookBeforeUpdate, model); err != nil {
+		return 0, err
+	}
+
 	val := reflect.ValueOf(model).Elem()
-	tipe := val.Type()
-
-	paramCount := 1
-
-	var setClause []string  // this is for set clause column to update
-	var vals []interface{}  // this for value that we want to update
-	var pkField string      // this is field that primary_key
-	var pkValue interface{} // this is for primary_key value to update
-	var col string
-
-	for i := 0; i < val.NumField(); i++ {
-		field := tipe.Field(i)
-		tag := field.Tag.Get("storm")
-
-		is_primary := strings.Contains(tag, "pk")
-		is_column := strings.Contains(tag, "column")
-
-		if is_primary {
-			pkField = field.Name
-			pkValue = val.Field(i).Interface()
-		} else {
-			// if in the tag we using column tag, for specify column name, then we use that
-			if is_column {
-				col = strings.Split(tag, ":")[1]
-			} else {
-				// otheriwise we use, the field name
-				col = strings.ToLower(field.Name)
-			}
-			if !val.Field(i).IsZero() {
-				setClause = append(setClause, fmt.Sprintf("%s = $%d", col, i))
-				vals = append(vals, val.Field(i).Interface())
-				paramCount++
+	info := s.schema.parseType(val.Type())
+	if err := validate(info, val, true); err != nil {
+		return 0, err
+	}
+
+	args := newParams() // this for value that we want to update, and its placeholder number
+
+	var setClause []string   // this is for set clause column to update
+	var pkColumn string      // this is the column of the primary_key, like user_id with `storm:"pk;column:user_id"`
+	var pkValue interface{}  // this is for primary_key value to update
+	var version *SchemaField // this is the version field, for optimistic locking
+
+	for _, field := range info.Fields {
+		fieldVal := field.Value(val)
+
+		if field.PK {
+			pkColumn = field.Column
+			pkValue = fieldVal.Interface()
+		} else if field.isVersion() {
+			version = field
+		} else if !fieldVal.IsZero() && field.writable() {
+			value, err := s.writeValue(field, fieldVal)
+			if err != nil {
+				return 0, err
 			}
+			setClause = append(setClause, fmt.Sprintf("%s = %s", field.Column, args.add(value)))
 		}
 	}
 
-	if pkField == "" {
-		return fmt.Errorf("no primary key is found for update")
+	if pkColumn == "" {
+		return 0, fmt.Errorf("no primary key is found for update")
+	}
+
+	// if every non primary key field is zero, we have nothing to SET, so we stop here
+	// rather than sending `UPDATE x SET  WHERE ...` to the database
+	if len(setClause) == 0 {
+		return 0, ErrNoFieldsToUpdate
+	}
+
+	table := s.tableName(info.Table)
+	// the row is found by its primary key, and the global scopes still apply
+	pkWhere := whereClause{rawExpr{fmt.Sprintf("%s = $1", pkColumn), []interface{}{pkValue}}}
+
+	// with a version field, the row is only updated if nobody changed it since it was read,
+	// and every update moves the version forward
+	if version != nil {
+		setClause = append(setClause, fmt.Sprintf("%s = %s + 1", version.Column, version.Column))
+		pkWhere = append(pkWhere, rawExpr{fmt.Sprintf("%s = $1", version.Column), []interface{}{version.Value(val).Interface()}})
+	}
+	where, whereArgs, err := s.scoped(table, pkWhere).build(len(args.args))
+	if err != nil {
+		return 0, err
 	}
 
-	vals = append(vals, pkValue)
 	q := fmt.Sprintf(`
-		UPDATE %s SET %s WHERE %s = $%d
+		UPDATE %s SET %s WHERE %s
 	`,
-		strings.ToLower(tipe.Name()+"s"),
+		table,
 		strings.Join(setClause, ", "),
-		pkField,
-		paramCount,
+		where,
 	)
-	_, err := s.db.Exec(q, vals...)
-	return err
+	var affected int64
+	if generated := info.generatedFields(); len(generated) > 0 {
+		affected, err = s.execReturning(q, append(args.args, whereArgs...), []reflect.Value{val}, generated)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		res, err := s.exec(q, append(args.args, whereArgs...)...)
+		if err != nil {
+			return 0, err
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return 0, err
+		}
+	}
+	s.forgetCached(table, model)
+	if version != nil && !s.dryRun {
+		if affected == 0 {
+			return 0, ErrStaleObject
+		}
+		incrementVersion(version.Alloc(val))
+	}
+	s.forgetIdentity(info, val)
+	s.write.affected = affected
+	return affected, s.callHook(HookAfterUpdate, model)
 }
 
 // Delete deletes a struct record from the database based on its primary key.
 // It uses reflection to detect the primary key field (`storm:"pk"`) and
 // generates a SQL DELETE statement.
-func (s *Storm) Delete(model interface{}) error {
+// It returns the number of rows affected, so a delete of a missing row can be detected (0 rows).
+// Use DeleteWhere to delete the records matching a condition instead.
+func (s *Storm) Delete(model interface{}) (int64, error) {
+	if err := checkStruct("model", model); err != nil {
+		return 0, err
+	}
+	s = s.startWrite()
+	if err := s.callHook(HookBeforeDelete, model); err != nil {
+		return 0, err
+	}
+
 	val := reflect.ValueOf(model).Elem()
-	tipe := val.Type()
+	info := s.schema.parseType(val.Type())
 
-	paramCount := 0
+	if info.PK == nil {
+		return 0, fmt.Errorf("no primary key is found for delete")
+	}
+	// the row is found by the column of its primary key, which can differ from the Go field name
+	pkValue := info.PK.Value(val).Interface()
+
+	table := s.tableName(info.Table)
+	pkWhere := whereClause{rawExpr{fmt.Sprintf("%s = $1", info.PK.Column), []interface{}{pkValue}}}
+
+	var q string
+	var args []interface{}
+	var err error
+	if soft, ok := model.(SoftDeletable); ok && !s.unscoped {
+		// the row is only marked as deleted, Unscoped deletes it for real
+		q, args, err = s.softDelete(soft, table, pkWhere)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		var where string
+		where, args, err = s.scoped(table, pkWhere).build(0)
+		if err != nil {
+			return 0, err
+		}
 
-	var pkField string
-	var pkValue interface{}
-	var vals []interface{}
+		q = fmt.Sprintf(`
+	DELETE FROM %s WHERE %s
+	`,
+			table,
+			where,
+		)
+	}
 
-	for i := 0; i < val.NumField(); i++ {
-		field := tipe.Field(i)
-		tag := field.Tag.Get("storm")
+	res, err := s.exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
 
-		col := field.Name
-		is_primary := strings.Contains(tag, "pk")
-		if is_primary {
-			pkField = col
-			pkValue = val.Field(i).Interface()
-			paramCount++
-		}
+	s.forgetCached(table, model)
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
 	}
+	s.forgetIdentity(info, val)
+	s.write.affected = affected
+	return affected, s.callHook(HookAfterDelete, model)
+}
 
-	vals = append(vals, pkValue)
+// DeleteWhere deletes the records of model matching condition, for one-off deletes that
+// don't start from a loaded record, and returns the number of rows deleted:
+//
+//	deleted, err := db.DeleteWhere(&User{}, "email_user = $1", "aji@handsome.com")
+//
+// The condition accepts the same forms as Query.Where (a string with arguments, a map, an Expr or
+// a partially filled model). Like Delete, a SoftDeletable model is only marked as deleted unless
+// the session is Unscoped. An empty condition returns ErrMissingWhereClause, and the delete hooks
+// are not called since there is no record to give them.
+func (s *Storm) DeleteWhere(model interface{}, condition interface{}, args ...interface{}) (int64, error) {
+	if err := checkStruct("model", model); err != nil {
+		return 0, err
+	}
+	info := s.schema.parseType(reflect.TypeOf(model).Elem())
+	table := s.tableName(info.Table)
 
-	q := fmt.Sprintf(`
-	DELETE FROM %s WHERE %s = $%d
-	`,
-		strings.ToLower(tipe.Name()+"s"),
-		pkField,
-		paramCount,
-	)
+	expr, err := s.toExpr(condition, args...)
+	if err != nil {
+		return 0, err
+	}
+	where := whereClause{expr}
+	if cond, _, err := where.build(0); err != nil {
+		return 0, err
+	} else if strings.TrimSpace(cond) == "" {
+		return 0, ErrMissingWhereClause
+	}
 
-	_, err := s.db.Exec(q, vals...)
+	var q string
+	var queryArgs []interface{}
+	if soft, ok := model.(SoftDeletable); ok && !s.unscoped {
+		q, queryArgs, err = s.softDelete(soft, table, where)
+		if err != nil {
+			return 0, err
+		}
+	} else {
+		var cond string
+		cond, queryArgs, err = s.scoped(table, where).build(0)
+		if err != nil {
+			return 0, err
+		}
+		q = fmt.Sprintf("DELETE FROM %s WHERE %s", table, cond)
+	}
 
-	return err
+	res, err := s.exec(q, queryArgs...)
+	if err != nil {
+		return 0, err
+	}
+	s.forgetCachedTable(table)
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	// we don't know which rows matched, so every remembered row of the model is forgotten
+	if s.identity != nil {
+		s.identity.forgetModel(info.Type)
+	}
+	return affected, nil
 }
@@ -0,0 +1,70 @@
+package storm_test
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pepega90/storm"
+)
+
+// newDryRun returns a postgres storm whose statements are only built, the database is never
+// reached, so the tests check the SQL storm generates.
+func newDryRun(t *testing.T, opts ...storm.Option) *storm.Storm {
+	t.Helper()
+	db, err := sql.Open("postgres", "postgres://localhost/storm_test?sslmode=disable")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return storm.NewWithDB(db, "postgres", opts...)
+}
+
+// tenantScope is a global scope limiting every statement to the tenant 7.
+func tenantScope(ctx context.Context, table string) storm.Expr {
+	return storm.Eq{"tenantid": 7}
+}
+
+// checkSQL runs fn in dry run and checks the statements it built, the whitespace of the SQL
+// built on several lines counts as a single space.
+func checkSQL(t *testing.T, db *storm.Storm, fn func(tx *storm.Storm) error, want ...storm.Statement) {
+	t.Helper()
+	got, err := db.ToSQL(fn)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d statements %v, want %d %v", len(got), got, len(want), want)
+	}
+	for i := range want {
+		if oneLine(got[i].SQL) != want[i].SQL {
+			t.Errorf("statement %d:\n got: %s\nwant: %s", i, got[i].SQL, want[i].SQL)
+		}
+		if !sameArgs(got[i].Args, want[i].Args) {
+			t.Errorf("statement %d args: got %v, want %v", i, got[i].Args, want[i].Args)
+		}
+	}
+}
+
+// anyArg matches any argument, like the time of a soft delete.
+type anyArg struct{}
+
+// sameArgs reports whether the arguments got are the ones of want.
+func sameArgs(got, want []interface{}) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range want {
+		if _, ok := want[i].(anyArg); !ok && !reflect.DeepEqual(got[i], want[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// oneLine returns sql with every run of whitespace replaced by a single space.
+func oneLine(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
@@ -0,0 +1,79 @@
+package stormtest
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"regexp"
+
+	"github.com/pepega90/storm"
+)
+
+// Expectation is one statement storm will execute, in the shape sqlmock expectations need:
+//
+//	exp, err := stormtest.ExpectInsert(db, &user)
+//	mock.ExpectExec(exp.Pattern).WithArgs(exp.Args...).WillReturnResult(sqlmock.NewResult(1, 1))
+//
+// where db is storm.NewWithDB(mockDB, "postgres").
+type Expectation struct {
+	SQL     string         // SQL, the exact statement
+	Pattern string         // Pattern, SQL quoted as regular expression, for sqlmock's default matcher
+	Args    []driver.Value // Args, the arguments of the statement, for WithArgs
+}
+
+// Expect returns the expectation of every statement fn would execute, in order,
+// fn runs against a dry run session so the database is never touched.
+func Expect(db *storm.Storm, fn func(tx *storm.Storm) error) ([]Expectation, error) {
+	stmts, err := db.ToSQL(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	expectations := make([]Expectation, len(stmts))
+	for i, stmt := range stmts {
+		args := make([]driver.Value, len(stmt.Args))
+		for j, arg := range stmt.Args {
+			args[j] = arg
+		}
+		expectations[i] = Expectation{
+			SQL:     stmt.SQL,
+			Pattern: regexp.QuoteMeta(stmt.SQL),
+			Args:    args,
+		}
+	}
+	return expectations, nil
+}
+
+// ExpectInsert returns the expectation of db.Insert(model).
+func ExpectInsert(db *storm.Storm, model interface{}) (Expectation, error) {
+	return expectOne(db, func(tx *storm.Storm) error {
+		return tx.Insert(model)
+	})
+}
+
+// ExpectUpdate returns the expectation of db.Update(model).
+func ExpectUpdate(db *storm.Storm, model interface{}) (Expectation, error) {
+	return expectOne(db, func(tx *storm.Storm) error {
+		_, err := tx.Update(model)
+		return err
+	})
+}
+
+// ExpectDelete returns the expectation of db.Delete(model).
+func ExpectDelete(db *storm.Storm, model interface{}) (Expectation, error) {
+	return expectOne(db, func(tx *storm.Storm) error {
+		_, err := tx.Delete(model)
+		return err
+	})
+}
+
+// expectOne returns the expectation of fn, which must execute exactly one statement.
+func expectOne(db *storm.Storm, fn func(tx *storm.Storm) error) (Expectation, error) {
+	expectations, err := Expect(db, fn)
+	if err != nil {
+		return Expectation{}, err
+	}
+	if len(expectations) != 1 {
+		return Expectation{}, fmt.Errorf("stormtest: expected 1 statement, got %d", len(expectations))
+	}
+	return expectations[0], nil
+}
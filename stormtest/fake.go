@@ -0,0 +1,312 @@
+// Package stormtest provides helpers to test code that use storm.
+package stormtest
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/pepega90/storm"
+)
+
+// Fake is an in-memory implementation of storm.Store, rows are kept per model type.
+// Use it to unit test repositories that depend on storm.Store instead of *storm.Storm:
+//
+//	fake := stormtest.NewFake()
+//	repo := NewUserRepository(fake)
+//
+// It behaves like Storm for the supported operations: Insert assigns an auto increment
+// integer primary key when it is zero, Update only writes non-zero fields, and Get returns
+// storm.ErrRecordNotFound. Model hooks are not called.
+type Fake struct {
+	mu     sync.Mutex
+	tables map[reflect.Type]*fakeTable
+}
+
+// fakeTable, is the rows of one model, in insertion order
+type fakeTable struct {
+	schema *storm.Schema
+	rows   []reflect.Value // copies of the model struct
+	nextID int64
+}
+
+// Fake must always satisfy storm.Store
+var _ storm.Store = (*Fake)(nil)
+
+// NewFake creates an empty Fake.
+func NewFake() *Fake {
+	return &Fake{tables: map[reflect.Type]*fakeTable{}}
+}
+
+// table returns the table of model, creating it the first time.
+func (f *Fake) table(model interface{}) (*fakeTable, error) {
+	schema, err := storm.ParseSchema(model, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t, ok := f.tables[schema.Type]
+	if !ok {
+		t = &fakeTable{schema: schema}
+		f.tables[schema.Type] = t
+	}
+	return t, nil
+}
+
+// find returns the index of the row whose primary key equals id, -1 if there is none.
+func (t *fakeTable) find(id interface{}) (int, error) {
+	if t.schema.PK == nil {
+		return -1, fmt.Errorf("stormtest: %s has no primary key", t.schema.Type.Name())
+	}
+	for i, row := range t.rows {
+		if equal(t.schema.PK.Value(row), id) {
+			return i, nil
+		}
+	}
+	return -1, nil
+}
+
+// Insert stores a copy of model, assigning the primary key if it is a zero integer.
+func (f *Fake) Insert(model interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, err := f.table(model)
+	if err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(model).Elem()
+	if pk := t.schema.PK; pk != nil {
+		pkVal := pk.Alloc(val)
+		if pkVal.IsZero() && pkVal.CanInt() {
+			t.nextID++
+			pkVal.SetInt(t.nextID)
+		} else if pkVal.CanInt() && pkVal.Int() > t.nextID {
+			t.nextID = pkVal.Int()
+		}
+
+		i, err := t.find(pkVal.Interface())
+		if err != nil {
+			return err
+		}
+		if i >= 0 {
+			return fmt.Errorf("stormtest: duplicate primary key %v for %s", pkVal.Interface(), t.schema.Table)
+		}
+	}
+
+	t.rows = append(t.rows, copyValue(val))
+	return nil
+}
+
+// Update writes the non-zero fields of model into the stored row with the same primary key.
+func (f *Fake) Update(model interface{}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, err := f.table(model)
+	if err != nil {
+		return 0, err
+	}
+	if t.schema.PK == nil {
+		return 0, fmt.Errorf("no primary key is found for update")
+	}
+
+	val := reflect.ValueOf(model).Elem()
+	changed := false
+	for _, field := range t.schema.Fields {
+		if !field.PK && !field.Value(val).IsZero() {
+			changed = true
+		}
+	}
+	if !changed {
+		return 0, storm.ErrNoFieldsToUpdate
+	}
+
+	i, err := t.find(t.schema.PK.Value(val).Interface())
+	if err != nil || i < 0 {
+		return 0, err
+	}
+	for _, field := range t.schema.Fields {
+		if fieldVal := field.Value(val); !field.PK && !fieldVal.IsZero() {
+			field.Alloc(t.rows[i]).Set(fieldVal)
+		}
+	}
+	return 1, nil
+}
+
+// Delete removes the stored row with the primary key of model.
+func (f *Fake) Delete(model interface{}) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, err := f.table(model)
+	if err != nil {
+		return 0, err
+	}
+	if t.schema.PK == nil {
+		return 0, fmt.Errorf("stormtest: %s has no primary key", t.schema.Type.Name())
+	}
+
+	i, err := t.find(t.schema.PK.Value(reflect.ValueOf(model).Elem()).Interface())
+	if err != nil || i < 0 {
+		return 0, err
+	}
+	t.rows = append(t.rows[:i], t.rows[i+1:]...)
+	return 1, nil
+}
+
+// Get copies the stored row whose primary key equals id into dest.
+func (f *Fake) Get(dest interface{}, id interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, err := f.table(dest)
+	if err != nil {
+		return err
+	}
+
+	i, err := t.find(id)
+	if err != nil {
+		return err
+	}
+	if i < 0 {
+		return storm.ErrRecordNotFound
+	}
+	reflect.ValueOf(dest).Elem().Set(copyValue(t.rows[i]))
+	return nil
+}
+
+// FindAll copies every stored row matching conditions into dest, a pointer to slice of model.
+// conditions can be nil, a map of column to value (a slice value means IN), or a partially
+// filled model whose non-zero fields must match. Expressions like storm.Gt are not supported.
+func (f *Fake) FindAll(dest interface{}, conditions interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, err := f.table(dest)
+	if err != nil {
+		return err
+	}
+
+	match, err := t.matcher(conditions)
+	if err != nil {
+		return err
+	}
+
+	sliceVal := reflect.ValueOf(dest).Elem()
+	sliceVal.Set(reflect.MakeSlice(sliceVal.Type(), 0, len(t.rows)))
+	for _, row := range t.rows {
+		if match(row) {
+			sliceVal.Set(reflect.Append(sliceVal, copyValue(row)))
+		}
+	}
+	return nil
+}
+
+// Len returns how many rows of model are stored.
+func (f *Fake) Len(model interface{}) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t, err := f.table(model)
+	if err != nil {
+		return 0
+	}
+	return len(t.rows)
+}
+
+// Reset removes every stored row.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.tables = map[reflect.Type]*fakeTable{}
+}
+
+// matcher turns conditions into a function that reports whether a stored row match.
+func (t *fakeTable) matcher(conditions interface{}) (func(row reflect.Value) bool, error) {
+	// wanted, is value the field must have
+	wanted := map[*storm.SchemaField]interface{}{}
+
+	switch c := conditions.(type) {
+	case nil:
+	case map[string]interface{}:
+		byColumn := map[string]*storm.SchemaField{}
+		for _, field := range t.schema.Fields {
+			byColumn[field.Column] = field
+		}
+		for col, v := range c {
+			field, ok := byColumn[col]
+			if !ok {
+				return nil, fmt.Errorf("stormtest: %s has no column %s", t.schema.Table, col)
+			}
+			wanted[field] = v
+		}
+	default:
+		val := reflect.ValueOf(conditions)
+		if val.Kind() == reflect.Ptr && !val.IsNil() {
+			val = val.Elem()
+		}
+		if val.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("stormtest: unsupported condition of type %T", conditions)
+		}
+		for _, field := range t.schema.Fields {
+			if fieldVal := field.Value(val); !fieldVal.IsZero() {
+				wanted[field] = fieldVal.Interface()
+			}
+		}
+	}
+
+	return func(row reflect.Value) bool {
+		for field, v := range wanted {
+			if !matchValue(field.Value(row), v) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// matchValue reports whether field equals v, or one of the elements when v is a slice (IN).
+func matchValue(field reflect.Value, v interface{}) bool {
+	if v == nil {
+		return field.IsZero()
+	}
+	list := reflect.ValueOf(v)
+	if _, isBytes := v.([]byte); !isBytes && list.Kind() == reflect.Slice {
+		for i := 0; i < list.Len(); i++ {
+			if equal(field, list.Index(i).Interface()) {
+				return true
+			}
+		}
+		return false
+	}
+	return equal(field, v)
+}
+
+// equal compares field with v, converting v to the field type first so 42 match an int64 field.
+func equal(field reflect.Value, v interface{}) bool {
+	val := reflect.ValueOf(v)
+	if !val.IsValid() {
+		return field.IsZero()
+	}
+	if val.Type() != field.Type() {
+		// only numbers are converted, converting an int to a string would give a rune
+		if !isNumber(val.Kind()) || !isNumber(field.Kind()) {
+			return false
+		}
+		val = val.Convert(field.Type())
+	}
+	return reflect.DeepEqual(field.Interface(), val.Interface())
+}
+
+func isNumber(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// copyValue returns an addressable copy of the struct val.
+func copyValue(val reflect.Value) reflect.Value {
+	c := reflect.New(val.Type()).Elem()
+	c.Set(val)
+	return c
+}
@@ -0,0 +1,293 @@
+package stormtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pepega90/storm"
+)
+
+// fixtures, is the rows to insert by table name
+type fixtures map[string][]map[string]interface{}
+
+// LoadFixtures loads every .json, .yml and .yaml file in dir into the database.
+// Each file maps table names to a list of rows, for example users.yml:
+//
+//	users:
+//	  - id: 1
+//	    name_user: aji
+//	    email_user: "aji@handsome.com"
+//
+// The tables found in the files are emptied first, then the rows are inserted with
+// referenced tables first (following the foreign keys, on PostgreSQL), so loading the same
+// fixtures again always gives the same data.
+// YAML support is limited to this shape: top level tables, each a list of flat rows of scalars.
+func LoadFixtures(db *storm.Storm, dir string) error {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	all := fixtures{}
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if file.IsDir() || (ext != ".json" && ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+
+		var parsed fixtures
+		if ext == ".json" {
+			parsed, err = parseJSONFixtures(data)
+		} else {
+			parsed, err = parseYAMLFixtures(data)
+		}
+		if err != nil {
+			return fmt.Errorf("stormtest: %s: %v", file.Name(), err)
+		}
+
+		for table, rows := range parsed {
+			all[table] = append(all[table], rows...)
+		}
+	}
+
+	order, err := insertOrder(db, all)
+	if err != nil {
+		return err
+	}
+
+	// delete children before parents, so the foreign keys never block us
+	for i := len(order) - 1; i >= 0; i-- {
+		if _, err := db.DB().Exec("DELETE FROM " + order[i]); err != nil {
+			return fmt.Errorf("stormtest: cleaning %s: %v", order[i], err)
+		}
+	}
+
+	for _, table := range order {
+		for _, row := range all[table] {
+			columns := make([]string, 0, len(row))
+			for col := range row {
+				columns = append(columns, col)
+			}
+			sort.Strings(columns)
+
+			values := make([]interface{}, len(columns))
+			for i, col := range columns {
+				values[i] = row[col]
+			}
+
+			if _, err := db.InsertInto(table).Columns(columns...).Values(values...).Exec(); err != nil {
+				return fmt.Errorf("stormtest: inserting into %s: %v", table, err)
+			}
+		}
+	}
+	return nil
+}
+
+// insertOrder sorts the tables of f so referenced tables come before the tables referencing them.
+// Tables without relation keep alphabetical order.
+func insertOrder(db *storm.Storm, f fixtures) ([]string, error) {
+	tables := make([]string, 0, len(f))
+	for table := range f {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	deps, err := foreignKeys(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var order []string
+	done := map[string]bool{}
+	visiting := map[string]bool{}
+	var visit func(table string)
+	visit = func(table string) {
+		if done[table] || visiting[table] {
+			// visiting means a cycle, we just keep going and let the database complain if it has to
+			return
+		}
+		visiting[table] = true
+		for _, parent := range deps[table] {
+			if _, ok := f[parent]; ok && parent != table {
+				visit(parent)
+			}
+		}
+		visiting[table] = false
+		done[table] = true
+		order = append(order, table)
+	}
+	for _, table := range tables {
+		visit(table)
+	}
+	return order, nil
+}
+
+// foreignKeys returns, for every table, the tables it references. Only PostgreSQL is introspected.
+func foreignKeys(db *storm.Storm) (map[string][]string, error) {
+	deps := map[string][]string{}
+	if db.Dialect().Name() != "postgres" {
+		return deps, nil
+	}
+
+	rows, err := db.DB().Query(`
+		SELECT DISTINCT tc.table_name, ccu.table_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.constraint_column_usage ccu
+			ON tc.constraint_name = ccu.constraint_name AND tc.table_schema = ccu.table_schema
+		WHERE tc.constraint_type = 'FOREIGN KEY'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var child, parent string
+		if err := rows.Scan(&child, &parent); err != nil {
+			return nil, err
+		}
+		deps[child] = append(deps[child], parent)
+	}
+	return deps, rows.Err()
+}
+
+// parseJSONFixtures parses {"table": [{"col": value}]}, integral numbers become int64.
+func parseJSONFixtures(data []byte) (fixtures, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var f fixtures
+	if err := decoder.Decode(&f); err != nil {
+		return nil, err
+	}
+	for _, rows := range f {
+		for _, row := range rows {
+			for col, v := range row {
+				if n, ok := v.(json.Number); ok {
+					if i, err := n.Int64(); err == nil {
+						row[col] = i
+					} else {
+						row[col], _ = n.Float64()
+					}
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+// parseYAMLFixtures parses the small YAML subset fixtures use:
+// top level `table:` keys, each with a list of `- column: value` rows.
+func parseYAMLFixtures(data []byte) (fixtures, error) {
+	f := fixtures{}
+	var table string
+	var row map[string]interface{}
+
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(stripComment(line), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+
+		switch {
+		case indent == 0:
+			// new table, like `users:` or `users: []`
+			key, value, ok := strings.Cut(trimmed, ":")
+			value = strings.TrimSpace(value)
+			if !ok || (value != "" && value != "[]") {
+				return nil, fmt.Errorf("line %d: expected `table:`", n+1)
+			}
+			table = strings.TrimSpace(key)
+			f[table] = []map[string]interface{}{}
+			row = nil
+
+		case strings.HasPrefix(trimmed, "-"):
+			// new row, the first column can be on the same line as the dash
+			if table == "" {
+				return nil, fmt.Errorf("line %d: row outside of a table", n+1)
+			}
+			row = map[string]interface{}{}
+			f[table] = append(f[table], row)
+			if rest := strings.TrimSpace(strings.TrimPrefix(trimmed, "-")); rest != "" {
+				if err := setYAMLColumn(row, rest); err != nil {
+					return nil, fmt.Errorf("line %d: %v", n+1, err)
+				}
+			}
+
+		default:
+			if row == nil {
+				return nil, fmt.Errorf("line %d: column outside of a row", n+1)
+			}
+			if err := setYAMLColumn(row, trimmed); err != nil {
+				return nil, fmt.Errorf("line %d: %v", n+1, err)
+			}
+		}
+	}
+	return f, nil
+}
+
+// setYAMLColumn parses `column: value` into row.
+func setYAMLColumn(row map[string]interface{}, s string) error {
+	key, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("expected `column: value`, got %q", s)
+	}
+	v, err := parseYAMLScalar(strings.TrimSpace(value))
+	if err != nil {
+		return err
+	}
+	row[strings.TrimSpace(key)] = v
+	return nil
+}
+
+// parseYAMLScalar parses a YAML scalar: quoted string, null, bool, integer, float or plain string.
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch {
+	case s == "" || s == "~" || s == "null":
+		return nil, nil
+	case s == "true":
+		return true, nil
+	case s == "false":
+		return false, nil
+	case strings.HasPrefix(s, `"`):
+		return strconv.Unquote(s)
+	case strings.HasPrefix(s, "'") && strings.HasSuffix(s, "'") && len(s) >= 2:
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// stripComment removes a # comment that is not inside quotes.
+func stripComment(line string) string {
+	inSingle, inDouble := false, false
+	for i, c := range line {
+		switch {
+		case c == '\'' && !inDouble:
+			inSingle = !inSingle
+		case c == '"' && !inSingle:
+			inDouble = !inDouble
+		case c == '#' && !inSingle && !inDouble && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
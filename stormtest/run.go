@@ -0,0 +1,35 @@
+package stormtest
+
+import (
+	"testing"
+
+	"github.com/pepega90/storm"
+)
+
+// Run runs fn inside a transaction that is always rolled back afterwards, so every test
+// starts from the same database without truncating tables:
+//
+//	func TestCreateUser(t *testing.T) {
+//		stormtest.Run(t, db, func(tx *storm.Tx) {
+//			if err := tx.Insert(&models.User{Name: "aji"}); err != nil {
+//				t.Fatal(err)
+//			}
+//		})
+//	}
+//
+// The rollback also happens when fn fails the test with t.Fatal or panics.
+func Run(t testing.TB, db *storm.Storm, fn func(tx *storm.Tx)) {
+	t.Helper()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("stormtest: begin transaction: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); err != nil {
+			t.Errorf("stormtest: rollback transaction: %v", err)
+		}
+	}()
+
+	fn(tx)
+}
@@ -0,0 +1,61 @@
+package storm
+
+import "time"
+
+// WithTimeZone sets the location of every time.Time storm handles: the times scanned into the
+// fields of a model are converted to loc, and the times written (fields and arguments of the
+// statements) are converted to loc before they are sent. With time.UTC the drivers, whatever
+// the time zone of the server or of the connection, always see and give the same instants:
+//
+//	db, err := storm.New("postgres", dsn, storm.WithTimeZone(time.UTC))
+//
+// Only the location changes, never the instant. Without this option times are kept as the
+// driver and the application give them.
+func WithTimeZone(loc *time.Location) Option {
+	return func(s *Storm) {
+		s.location = loc
+	}
+}
+
+// inLocation returns value, a time scanned from the database, in the location of WithTimeZone,
+// other values are returned as they are.
+func (s *Storm) inLocation(value interface{}) interface{} {
+	if s.location == nil {
+		return value
+	}
+	if t, ok := value.(time.Time); ok {
+		return t.In(s.location)
+	}
+	return value
+}
+
+// normalizeTimes converts the time arguments of a statement to the location of WithTimeZone.
+func (s *Storm) normalizeTimes(args []interface{}) []interface{} {
+	if s.location == nil {
+		return args
+	}
+	var normalized []interface{}
+	for i, arg := range args {
+		var t time.Time
+		switch v := arg.(type) {
+		case time.Time:
+			t = v
+		case *time.Time:
+			if v == nil {
+				continue
+			}
+			t = *v
+		default:
+			continue
+		}
+		// copy before the first change, args can be the slice of the caller
+		if normalized == nil {
+			normalized = append([]interface{}(nil), args...)
+		}
+		normalized[i] = t.In(s.location)
+	}
+	if normalized == nil {
+		return args
+	}
+	return normalized
+}
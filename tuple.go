@@ -0,0 +1,101 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// WhereTupleIn adds the condition that the row value of columns is one of values, for
+// composite key lookups. values is a slice of rows, every row is a slice or array with one
+// value per column:
+//
+//	keys := [][2]interface{}{{1, 10}, {1, 11}, {2, 10}}
+//	db.From(&Member{}).WhereTupleIn([]string{"tenant_id", "id"}, keys).Select(&members)
+//	// WHERE (tenant_id, id) IN (($1, $2), ($3, $4), ($5, $6))
+//
+// An empty values never match, like an empty In, a nil values is an error.
+func (q *Query) WhereTupleIn(columns []string, values interface{}) *Query {
+	if err := checkColumns(columns); err != nil {
+		q.err = err
+		return q
+	}
+
+	rows := reflect.ValueOf(values)
+	if !isList(values) {
+		q.err = fmt.Errorf("storm: WhereTupleIn values must be a slice of rows, got %T", values)
+		return q
+	}
+	if rows.Len() == 0 {
+		q.where = append(q.where, rawExpr{"1=0", nil})
+		return q
+	}
+
+	args := newParams()
+	tuples := make([]string, rows.Len())
+	for i := 0; i < rows.Len(); i++ {
+		row := reflect.ValueOf(rows.Index(i).Interface())
+		if (row.Kind() != reflect.Slice && row.Kind() != reflect.Array) || row.Len() != len(columns) {
+			q.err = fmt.Errorf("storm: WhereTupleIn row %d must have %d values", i, len(columns))
+			return q
+		}
+		placeholders := make([]string, row.Len())
+		for j := 0; j < row.Len(); j++ {
+			placeholders[j] = args.add(row.Index(j).Interface())
+		}
+		tuples[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	sql := fmt.Sprintf("(%s) IN (%s)", strings.Join(columns, ", "), strings.Join(tuples, ", "))
+	q.where = append(q.where, rawExpr{sql, args.args})
+	return q
+}
+
+// WhereTuple adds a row value comparison of columns with values, one per column. With < or >
+// it is the condition of keyset pagination over several columns:
+//
+//	// the page after the last row seen, ordered by created_at then id
+//	db.From(&Post{}).
+//		WhereTuple([]string{"created_at", "id"}, ">", last.CreatedAt, last.ID).
+//		OrderByAsc("created_at", "id").
+//		Limit(20).
+//		Select(&posts)
+//	// WHERE (created_at, id) > ($1, $2) ORDER BY created_at ASC, id ASC LIMIT $3
+func (q *Query) WhereTuple(columns []string, op string, values ...interface{}) *Query {
+	if err := checkColumns(columns); err != nil {
+		q.err = err
+		return q
+	}
+	switch op {
+	case "=", "<>", "!=", "<", "<=", ">", ">=":
+	default:
+		q.err = fmt.Errorf("storm: invalid comparison operator %q in WhereTuple", op)
+		return q
+	}
+	if len(values) != len(columns) {
+		q.err = fmt.Errorf("storm: WhereTuple has %d values for %d columns", len(values), len(columns))
+		return q
+	}
+
+	args := newParams()
+	placeholders := make([]string, len(values))
+	for i, v := range values {
+		placeholders[i] = args.add(v)
+	}
+	sql := fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), op, strings.Join(placeholders, ", "))
+	q.where = append(q.where, rawExpr{sql, args.args})
+	return q
+}
+
+// checkColumns returns an error unless columns are at least one column name.
+func checkColumns(columns []string) error {
+	if len(columns) == 0 {
+		return fmt.Errorf("storm: a row value needs at least one column")
+	}
+	for _, column := range columns {
+		if !isIdentifier(column) {
+			return fmt.Errorf("storm: invalid column name %q", column)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package storm
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// executor is what storm needs to run statements, *sql.DB, *sql.Conn and the txConn of a transaction satisfy it.
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// Tx is a database transaction. It embeds *Storm, so every operation of storm
+// (Insert, Update, From, ...) called on Tx runs inside the transaction.
+type Tx struct {
+	*Storm
+	tx *sql.Tx
+}
+
+// Begin starts a transaction, using the context of s.
+func (s *Storm) Begin() (*Tx, error) {
+	return s.BeginTx(s.ctx, nil)
+}
+
+// BeginTx starts a transaction with ctx and opts (isolation level, read only).
+// The returned Tx use ctx for its statements.
+func (s *Storm) BeginTx(ctx context.Context, opts *sql.TxOptions) (*Tx, error) {
+	if s.inTx() {
+		return nil, fmt.Errorf("storm: transaction already started")
+	}
+
+	if s.readOnly {
+		readOnly := sql.TxOptions{ReadOnly: true}
+		if opts != nil {
+			readOnly.Isolation = opts.Isolation
+		}
+		opts = &readOnly
+	}
+
+	tx, err := s.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	session := s.Session(&SessionConfig{Context: ctx})
+	session.conn = newTxConn(tx)
+	session.readOnlyTx = s.readOnly
+	session.pendingChanges = &[]ChangeEvent{}
+	t := &Tx{Storm: session, tx: tx}
+
+	for _, hook := range s.beginHooks {
+		if err := hook(t); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Commit commits the transaction, then emits the change events of the transaction.
+func (t *Tx) Commit() error {
+	if err := t.tx.Commit(); err != nil {
+		return err
+	}
+
+	changes := *t.pendingChanges
+	*t.pendingChanges = nil
+	for _, e := range changes {
+		t.events.publish(e)
+	}
+	return nil
+}
+
+// Rollback aborts the transaction, its change events are discarded.
+func (t *Tx) Rollback() error {
+	*t.pendingChanges = nil
+	return t.tx.Rollback()
+}
+
+// SetLocal sets the postgres setting name to value until the end of the transaction,
+// like SET LOCAL but with a bound value: SELECT set_config($1, $2, true).
+// Row-level security policies can read it with current_setting('app.current_tenant').
+func (t *Tx) SetLocal(name, value string) error {
+	_, err := t.exec("SELECT set_config($1, $2, true)", name, value)
+	return err
+}
+
+// SQLTx returns the underlying *sql.Tx so you can execute raw statements inside the transaction.
+func (t *Tx) SQLTx() *sql.Tx {
+	return t.tx
+}
+
+// Transaction runs fn inside a transaction. The transaction is committed when fn returns nil,
+// and rolled back when fn returns an error or panics (the panic is re-raised after the rollback).
+//
+//	err := db.Transaction(func(tx *storm.Tx) error {
+//		if err := tx.Insert(&order); err != nil {
+//			return err
+//		}
+//		_, err := tx.Update(&stock)
+//		return err
+//	})
+func (s *Storm) Transaction(fn func(tx *Tx) error) (err error) {
+	tx, err := s.Begin()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
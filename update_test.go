@@ -0,0 +1,48 @@
+package storm_test
+
+import (
+	"testing"
+
+	"github.com/pepega90/storm"
+)
+
+// document is a model updated with optimistic locking.
+type document struct {
+	ID      int `storm:"pk"`
+	Title   string
+	Version int `storm:"version"`
+}
+
+func TestUpdate(t *testing.T) {
+	db := newDryRun(t)
+	db.RegisterScope("tenant", tenantScope)
+
+	// only the non-zero fields are set, the row is found by its primary key within the scopes
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		_, err := tx.Update(&user{ID: 3, Name: "a"})
+		return err
+	}, storm.Statement{
+		SQL:  "UPDATE users SET name = $1 WHERE (id = $2) AND (tenantid = $3)",
+		Args: []interface{}{"a", 3, 7},
+	})
+
+	checkSQL(t, db.Unscoped(), func(tx *storm.Storm) error {
+		_, err := tx.Update(&user{ID: 3, Name: "a"})
+		return err
+	}, storm.Statement{
+		SQL:  "UPDATE users SET name = $1 WHERE id = $2",
+		Args: []interface{}{"a", 3},
+	})
+
+	checkSQL(t, db, func(tx *storm.Storm) error {
+		_, err := tx.Update(&document{ID: 3, Title: "a", Version: 2})
+		return err
+	}, storm.Statement{
+		SQL:  "UPDATE documents SET title = $1, version = version + 1 WHERE (id = $2) AND (version = $3) AND (tenantid = $4)",
+		Args: []interface{}{"a", 3, 2, 7},
+	})
+
+	if _, err := db.Update(&user{ID: 3}); err != storm.ErrNoFieldsToUpdate {
+		t.Errorf("got %v, want ErrNoFieldsToUpdate", err)
+	}
+}
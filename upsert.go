@@ -0,0 +1,168 @@
+package storm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// OnConflict is the conflict target and action of an Upsert.
+// The zero value targets the primary key and updates every inserted column.
+type OnConflict struct {
+	Columns     []string // Columns, conflict target, the columns of a unique index, like email
+	Where       string   // Where, predicate of a partial unique index, like "deleted_at IS NULL"
+	Constraint  string   // Constraint, name of a unique constraint, used instead of Columns
+	Update      []string // Update, columns to update on conflict, empty means every inserted column except the target
+	DoNothing   bool     // DoNothing, keep the existing row as it is
+	OnlyChanged bool     // OnlyChanged, leave the existing row alone when the updated columns already have the inserted values
+}
+
+// Upsert inserts model, or updates the existing row when it conflicts with a unique index.
+// The primary key is inserted too when it is not zero. It calls the insert hooks.
+//
+//	err := db.Upsert(&user, storm.OnConflict{
+//		Columns: []string{"email_user"},
+//		Where:   "deleted_at IS NULL", // the unique index is partial
+//	})
+//
+// is `INSERT INTO users (...) VALUES (...) ON CONFLICT (email_user) WHERE deleted_at IS NULL
+// DO UPDATE SET name_user = EXCLUDED.name_user`.
+// On mysql there is no conflict target, ON DUPLICATE KEY UPDATE is used and Columns, Where and
+// Constraint only decide which columns are not updated.
+//
+// With OnlyChanged the existing row is only updated when one of the updated columns changes,
+// `DO UPDATE SET ... WHERE users.name_user IS DISTINCT FROM EXCLUDED.name_user`, so a no-op
+// write doesn't fire the update triggers (like one setting updated_at). A column that always
+// changes, like an updated_at set by the application, must be left out of Update for that.
+// mysql already leaves a row whose values don't change as it is, there the option does nothing.
+func (s *Storm) Upsert(model interface{}, conflict OnConflict) error {
+	if err := checkStruct("model", model); err != nil {
+		return err
+	}
+	if err := s.callHook(HookBeforeInsert, model); err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(model).Elem()
+	info := s.schema.parseType(val.Type())
+	setDiscriminator(info, val)
+	if err := validate(info, val, false); err != nil {
+		return err
+	}
+
+	var columns []string
+	var placeholders []string
+	args := newParams()
+
+	for _, field := range info.Fields {
+		fieldVal := field.Value(val)
+		// a zero auto increment key is generated by the database, like Insert does
+		if (field.autoIncrement() && fieldVal.IsZero()) || !field.writable() {
+			continue
+		}
+
+		value, err := s.writeValue(field, fieldVal)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, field.Column)
+		placeholders = append(placeholders, args.add(value))
+	}
+
+	target := conflict.Columns
+	if len(target) == 0 && conflict.Constraint == "" {
+		if info.PK == nil {
+			return fmt.Errorf("storm: Upsert of %s needs a conflict target, it has no primary key", info.Type.Name())
+		}
+		target = []string{info.PK.Column}
+	}
+
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		s.tableName(info.Table),
+		strings.Join(columns, ", "),
+		strings.Join(placeholders, ", "),
+	)
+
+	clause, err := s.conflictClause(conflict, info.Table, target, columns)
+	if err != nil {
+		return err
+	}
+	q += " " + clause
+
+	if generated := info.generatedFields(); len(generated) > 0 {
+		if _, err := s.execReturning(q, args.args, []reflect.Value{val}, generated); err != nil {
+			return err
+		}
+	} else if _, err := s.exec(q, args.args...); err != nil {
+		return err
+	}
+
+	s.forgetCached(s.tableName(info.Table), model)
+	s.forgetIdentity(info, val)
+	return s.callHook(HookAfterInsert, model)
+}
+
+// conflictClause builds the ON CONFLICT (or ON DUPLICATE KEY) clause of an upsert into table
+// that inserts columns, target is the resolved conflict target columns.
+func (s *Storm) conflictClause(conflict OnConflict, table string, target, columns []string) (string, error) {
+	update := conflict.Update
+	if len(update) == 0 {
+		inTarget := map[string]bool{}
+		for _, col := range target {
+			inTarget[col] = true
+		}
+		for _, col := range columns {
+			if !inTarget[col] {
+				update = append(update, col)
+			}
+		}
+	}
+
+	if s.dialect.Name() == "mysql" {
+		// mysql has no DO NOTHING, setting a column to itself is the usual way to ignore the row
+		if conflict.DoNothing || len(update) == 0 {
+			return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s = %s", columns[0], columns[0]), nil
+		}
+		sets := make([]string, len(update))
+		for i, col := range update {
+			sets[i] = fmt.Sprintf("%s = VALUES(%s)", col, col)
+		}
+		return "ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+	}
+
+	var clause string
+	if conflict.Constraint != "" {
+		if s.dialect.Name() != "postgres" {
+			return "", fmt.Errorf("storm: conflict on constraint is only supported on postgres")
+		}
+		clause = "ON CONFLICT ON CONSTRAINT " + conflict.Constraint
+	} else {
+		clause = fmt.Sprintf("ON CONFLICT (%s)", strings.Join(target, ", "))
+		if conflict.Where != "" {
+			clause += " WHERE " + conflict.Where
+		}
+	}
+
+	if conflict.DoNothing || len(update) == 0 {
+		return clause + " DO NOTHING", nil
+	}
+	sets := make([]string, len(update))
+	for i, col := range update {
+		sets[i] = fmt.Sprintf("%s = EXCLUDED.%s", col, col)
+	}
+	clause += " DO UPDATE SET " + strings.Join(sets, ", ")
+
+	if conflict.OnlyChanged {
+		// the existing row is referenced by the table name without its schema
+		distinct := "IS DISTINCT FROM"
+		if s.dialect.Name() == "sqlite3" {
+			distinct = "IS NOT"
+		}
+		changes := make([]string, len(update))
+		for i, col := range update {
+			changes[i] = fmt.Sprintf("%s.%s %s EXCLUDED.%s", table, col, distinct, col)
+		}
+		clause += " WHERE " + strings.Join(changes, " OR ")
+	}
+	return clause, nil
+}
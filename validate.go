@@ -0,0 +1,167 @@
+package storm
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is a validation rule a field of a model broke, see ValidationErrors.
+type FieldError struct {
+	Field   string // Field, Go field name, like "Email"
+	Rule    string // Rule, the broken rule as it is in the tag, like "max:255"
+	Message string // Message, what is wrong, like "must be at most 255 characters"
+}
+
+func (e FieldError) Error() string {
+	return e.Field + " " + e.Message
+}
+
+// ValidationErrors is returned by Insert, Update, Upsert and InsertAll when fields of the model
+// break the rules of their tags, with every broken rule, nothing is sent to the database.
+// The rules are:
+//
+//	notnull  a pointer, slice, map or interface is not nil
+//	required the value is not the zero value, like "" or 0
+//	min:N    a number is at least N, a string (in characters), slice or map has at least N elements
+//	max:N    a number is at most N, a string (in characters), slice or map has at most N elements
+//	email    a string, when not empty, is an email address
+//
+// For example:
+//
+//	type User struct {
+//		ID    int     `storm:"pk"`
+//		Name  string  `storm:"required;max:255"`
+//		Email *string `storm:"notnull;email"`
+//	}
+//
+// Insert checks every field it writes, Update only the non-zero fields, which are the ones it writes.
+// errors.Is(err, ErrValidation) reports whether err is a ValidationErrors.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Error()
+	}
+	return "storm: validation failed: " + strings.Join(messages, ", ")
+}
+
+// Unwrap returns ErrValidation, so errors.Is finds it.
+func (e ValidationErrors) Unwrap() error {
+	return ErrValidation
+}
+
+// validationRules, the tags checked by validate, in the order they are checked
+var validationRules = []string{"notnull", "required", "min", "max", "email"}
+
+// validate checks the fields of val, a model of info, against the rules of their tags.
+// Insert checks every field it writes, Update (update true) only the non-zero ones.
+func validate(info *Schema, val reflect.Value, update bool) error {
+	var errs ValidationErrors
+	for _, field := range info.Fields {
+		if !field.writable() {
+			continue
+		}
+		fieldVal := field.Value(val)
+		if update && fieldVal.IsZero() {
+			continue
+		}
+		if field.autoIncrement() && fieldVal.IsZero() {
+			continue
+		}
+
+		for _, rule := range validationRules {
+			arg, ok := field.Tag[rule]
+			if !ok {
+				continue
+			}
+			if message := checkRule(rule, arg, fieldVal); message != "" {
+				if arg != "" {
+					rule += ":" + arg
+				}
+				errs = append(errs, FieldError{Field: field.Name, Rule: rule, Message: message})
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// checkRule checks v against rule with its argument arg, and returns what is wrong,
+// or an empty string when v follows the rule.
+func checkRule(rule, arg string, v reflect.Value) string {
+	switch rule {
+	case "notnull":
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			if v.IsNil() {
+				return "must not be null"
+			}
+		}
+		return ""
+	case "required":
+		if v.IsZero() {
+			return "is required"
+		}
+		return ""
+	}
+
+	// the other rules are about the value, a nil pointer has none (notnull is about that)
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch rule {
+	case "min", "max":
+		limit, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return fmt.Sprintf("has an invalid %s rule %q", rule, arg)
+		}
+		n, unit, ok := measure(v)
+		if !ok {
+			return ""
+		}
+		if rule == "min" && n < limit {
+			return fmt.Sprintf("must be at least %s%s", arg, unit)
+		}
+		if rule == "max" && n > limit {
+			return fmt.Sprintf("must be at most %s%s", arg, unit)
+		}
+	case "email":
+		if v.Kind() != reflect.String || v.String() == "" {
+			return ""
+		}
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "must be an email address"
+		}
+	}
+	return ""
+}
+
+// measure returns what min and max compare for v: a number itself, the characters of a string,
+// the elements of a slice or a map, with the unit of the message. ok is false for the other kinds.
+func measure(v reflect.Value) (n float64, unit string, ok bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), "", true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), "", true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), "", true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), " characters", true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), " elements", true
+	}
+	return 0, "", false
+}
@@ -0,0 +1,210 @@
+package storm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InsertBuilder builds an INSERT statement from table and column names,
+// for the cases where no struct exists for the table.
+type InsertBuilder struct {
+	storm   *Storm
+	table   string
+	columns []string
+	rows    [][]interface{} // rows, every call to Values add one row
+}
+
+// InsertInto starts an INSERT statement into table.
+// Example: db.InsertInto("users").Columns("name_user", "email_user").Values("aji", "aji@handsome.com").Exec()
+func (s *Storm) InsertInto(table string) *InsertBuilder {
+	return &InsertBuilder{storm: s, table: s.tableName(table)}
+}
+
+// Columns sets the columns to insert.
+func (b *InsertBuilder) Columns(columns ...string) *InsertBuilder {
+	b.columns = append(b.columns, columns...)
+	return b
+}
+
+// Values adds one row of values, in the same order as Columns.
+// Call it several times to insert several rows in one statement.
+func (b *InsertBuilder) Values(values ...interface{}) *InsertBuilder {
+	b.rows = append(b.rows, values)
+	return b
+}
+
+// Exec executes the INSERT statement and returns the number of rows inserted.
+func (b *InsertBuilder) Exec() (int64, error) {
+	if len(b.columns) == 0 || len(b.rows) == 0 {
+		return 0, fmt.Errorf("storm: insert into %s needs columns and values", b.table)
+	}
+
+	args := newParams()
+	var valueRows []string
+	for i, row := range b.rows {
+		if len(row) != len(b.columns) {
+			return 0, fmt.Errorf("storm: insert into %s row %d has %d values for %d columns", b.table, i, len(row), len(b.columns))
+		}
+
+		placeholders := make([]string, len(row))
+		for j, v := range row {
+			placeholders[j] = args.add(v)
+		}
+		valueRows = append(valueRows, "("+strings.Join(placeholders, ", ")+")")
+	}
+
+	q := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		b.table,
+		strings.Join(b.columns, ", "),
+		strings.Join(valueRows, ", "),
+	)
+
+	res, err := b.storm.exec(q, args.args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// UpdateBuilder builds an UPDATE statement from column names and values,
+// useful for partial dynamic writes where building a whole struct doesn't make sense.
+type UpdateBuilder struct {
+	storm   *Storm
+	table   string
+	columns []string
+	values  []interface{}
+	where   whereClause
+	err     error
+}
+
+// UpdateTable starts an UPDATE statement on table.
+// Example: db.UpdateTable("users").Set("name_user", "aji").Where("id = $1", 5).Exec()
+func (s *Storm) UpdateTable(table string) *UpdateBuilder {
+	return &UpdateBuilder{storm: s, table: s.tableName(table)}
+}
+
+// Set adds `column = value` to the SET clause.
+func (b *UpdateBuilder) Set(column string, value interface{}) *UpdateBuilder {
+	b.columns = append(b.columns, column)
+	b.values = append(b.values, value)
+	return b
+}
+
+// Where adds a WHERE condition to the update, numbered from $1 like Query.Where,
+// storm takes care of renumbering it after the SET values.
+func (b *UpdateBuilder) Where(condition interface{}, args ...interface{}) *UpdateBuilder {
+	expr, err := b.storm.toExpr(condition, args...)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.where = append(b.where, expr)
+	return b
+}
+
+// WhereExpr adds a condition built with the expression DSL to the update.
+func (b *UpdateBuilder) WhereExpr(expr Expr) *UpdateBuilder {
+	b.where = append(b.where, expr)
+	return b
+}
+
+// Exec executes the UPDATE statement and returns the number of rows affected.
+// An update without Where returns ErrMissingWhereClause, so we never update a whole table by accident.
+func (b *UpdateBuilder) Exec() (int64, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if len(b.columns) == 0 {
+		return 0, ErrNoFieldsToUpdate
+	}
+
+	args := newParams()
+	setClause := make([]string, len(b.columns))
+	for i, col := range b.columns {
+		setClause[i] = fmt.Sprintf("%s = %s", col, args.add(b.values[i]))
+	}
+
+	where, whereArgs, err := b.where.build(len(args.args))
+	if err != nil {
+		return 0, err
+	}
+	if where == "" {
+		return 0, ErrMissingWhereClause
+	}
+	where, whereArgs, err = b.storm.scoped(b.table, b.where).build(len(args.args))
+	if err != nil {
+		return 0, err
+	}
+
+	q := fmt.Sprintf("UPDATE %s SET %s WHERE %s",
+		b.table,
+		strings.Join(setClause, ", "),
+		where,
+	)
+
+	res, err := b.storm.exec(q, append(args.args, whereArgs...)...)
+	if err != nil {
+		return 0, err
+	}
+	b.storm.forgetCachedTable(b.table)
+	return res.RowsAffected()
+}
+
+// DeleteBuilder builds a DELETE statement from a table name and a condition.
+type DeleteBuilder struct {
+	storm *Storm
+	table string
+	where whereClause
+	err   error
+}
+
+// DeleteFrom starts a DELETE statement on table.
+// Example: db.DeleteFrom("users").Where("email_user = $1", "aji@handsome.com").Exec()
+func (s *Storm) DeleteFrom(table string) *DeleteBuilder {
+	return &DeleteBuilder{storm: s, table: s.tableName(table)}
+}
+
+// Where adds a WHERE condition to the delete, it accepts the same conditions as Query.Where.
+func (b *DeleteBuilder) Where(condition interface{}, args ...interface{}) *DeleteBuilder {
+	expr, err := b.storm.toExpr(condition, args...)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	b.where = append(b.where, expr)
+	return b
+}
+
+// WhereExpr adds a condition built with the expression DSL to the delete.
+func (b *DeleteBuilder) WhereExpr(expr Expr) *DeleteBuilder {
+	b.where = append(b.where, expr)
+	return b
+}
+
+// Exec executes the DELETE statement and returns the number of rows deleted.
+// A delete without Where returns ErrMissingWhereClause, so we never empty a whole table by accident.
+func (b *DeleteBuilder) Exec() (int64, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	where, args, err := b.where.build(0)
+	if err != nil {
+		return 0, err
+	}
+	if where == "" {
+		return 0, ErrMissingWhereClause
+	}
+	where, args, err = b.storm.scoped(b.table, b.where).build(0)
+	if err != nil {
+		return 0, err
+	}
+
+	q := fmt.Sprintf("DELETE FROM %s WHERE %s", b.table, where)
+
+	res, err := b.storm.exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
+	b.storm.forgetCachedTable(b.table)
+	return res.RowsAffected()
+}